@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// resolveAlarmArns returns the ARNs of the named CloudWatch alarms. Values
+// that are already ARNs are returned unchanged.
+func resolveAlarmArns(ctx context.Context, api cloudwatchiface.CloudWatchAPI, alarms []string) ([]string, error) {
+	names := []string{}
+	for _, alarm := range alarms {
+		if !strings.HasPrefix(alarm, "arn:") {
+			names = append(names, alarm)
+		}
+	}
+
+	arns := map[string]string{}
+	if len(names) > 0 {
+		err := api.DescribeAlarmsPagesWithContext(ctx, &cloudwatch.DescribeAlarmsInput{AlarmNames: aws.StringSlice(names)}, func(page *cloudwatch.DescribeAlarmsOutput, lastPage bool) bool {
+			for _, alarm := range page.MetricAlarms {
+				arns[*alarm.AlarmName] = *alarm.AlarmArn
+			}
+			return !lastPage
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "describing rollback alarms")
+		}
+	}
+
+	resolved := []string{}
+	for _, alarm := range alarms {
+		if strings.HasPrefix(alarm, "arn:") {
+			resolved = append(resolved, alarm)
+			continue
+		}
+
+		arn, ok := arns[alarm]
+		if !ok {
+			return nil, errors.Errorf("rollback alarm %s does not exist", alarm)
+		}
+		resolved = append(resolved, arn)
+	}
+
+	return resolved, nil
+}
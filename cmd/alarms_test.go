@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type fakeCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	alarms []*cloudwatch.MetricAlarm
+}
+
+func (f *fakeCloudWatch) DescribeAlarmsPagesWithContext(ctx aws.Context, input *cloudwatch.DescribeAlarmsInput, cb func(*cloudwatch.DescribeAlarmsOutput, bool) bool, opts ...request.Option) error {
+	cb(&cloudwatch.DescribeAlarmsOutput{MetricAlarms: f.alarms}, true)
+	return nil
+}
+
+func TestResolveAlarmArns(t *testing.T) {
+	api := &fakeCloudWatch{alarms: []*cloudwatch.MetricAlarm{
+		{AlarmName: aws.String("api-5xx"), AlarmArn: aws.String("arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:api-5xx")},
+	}}
+
+	arns, err := resolveAlarmArns(context.Background(), api, []string{"api-5xx", "arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:latency"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:api-5xx",
+		"arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:latency",
+	}, arns)
+
+	_, err = resolveAlarmArns(context.Background(), api, []string{"missing"})
+	assert.EqualError(t, err, "rollback alarm missing does not exist")
+}
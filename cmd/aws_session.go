@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/cmd/mask"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
+	"github.com/spf13/viper"
 	"log"
 	"os"
 )
@@ -28,10 +35,12 @@ func awsSession(profile, region string) *session.Session {
 		logger := log.New(os.Stderr, "", log.LstdFlags)
 		sessOpts.Config.LogLevel = aws.LogLevel(aws.LogDebugWithHTTPBody)
 		sessOpts.Config.Logger = aws.LoggerFunc(func(args ...interface{}) {
-			logger.Println(args...)
+			logger.Println(mask.String(fmt.Sprint(args...)))
 		})
 	}
 
+	request.WithRetryer(&sessOpts.Config, retryPolicy().Retryer())
+
 	userAgentHandler := request.NamedHandler{
 		Name: "stackit.UserAgentHandler",
 		Fn:   request.MakeAddToUserAgentHandler("stackit", version),
@@ -43,3 +52,27 @@ func awsSession(profile, region string) *session.Session {
 
 	return sess
 }
+
+// retryPolicy returns the policy for retrying throttled API calls, as
+// configured with the --retry-* flags or config file.
+func retryPolicy() retry.Policy {
+	policy := retry.DefaultPolicy
+
+	if attempts := viper.GetInt("retry-max-attempts"); attempts > 0 {
+		policy.MaxAttempts = attempts
+	}
+	if delay := viper.GetDuration("retry-base-delay"); delay > 0 {
+		policy.BaseDelay = delay
+	}
+	if delay := viper.GetDuration("retry-max-delay"); delay > 0 {
+		policy.MaxDelay = delay
+	}
+
+	return policy
+}
+
+func newStackit(sess *session.Session) *stackit.Stackit {
+	sit := stackit.NewStackit(cloudformation.New(sess), sts.New(sess))
+	sit.SetRetryPolicy(retryPolicy())
+	return sit
+}
@@ -0,0 +1,64 @@
+// Copyright © 2017 Aidan Steele <aidan.steele@glassechidna.com.au>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel stack update in progress and wait for rollback",
+	Run: func(cmd *cobra.Command, args []string) {
+		region := viper.GetString("region")
+		profile := viper.GetString("profile")
+		stackName := viper.GetString("stack-name")
+
+		events := make(chan stackit.TailStackEvent)
+
+		sess := awsSession(profile, region)
+		sit := newStackit(sess)
+
+		ctx, end := honey.RootContext()
+		defer end()
+
+		printerCtx, printerCancel := context.WithCancel(ctx)
+		defer printerCancel()
+		go printUntilDone(printerCtx, events, cmd.OutOrStderr())
+
+		err := sit.Cancel(ctx, stackName, events)
+		if err != nil {
+			panic(err)
+		}
+
+		stack, err := sit.Describe(ctx, stackName)
+		if err != nil {
+			panic(err)
+		}
+
+		if *stack.StackStatus != cloudformation.StackStatusUpdateRollbackComplete {
+			defaultExiter(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(cancelCmd)
+}
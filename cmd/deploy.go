@@ -0,0 +1,324 @@
+// Copyright © 2017 Aidan Steele <aidan.steele@glassechidna.com.au>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/cmd/mask"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/glassechidna/stackit/pkg/stackit/manifest"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type deployResult struct {
+	id       string
+	status   string
+	duration time.Duration
+	changes  int
+	outputs  map[string]string
+	err      error
+}
+
+const (
+	deployStatusSucceeded = "SUCCEEDED"
+	deployStatusNoChanges = "NO CHANGES"
+	deployStatusFailed    = "FAILED"
+	deployStatusSkipped   = "SKIPPED"
+)
+
+// syncWriter serialises writes from the goroutines deploying stacks
+// concurrently, so that lines from different stacks don't interleave.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.w.Write(p)
+}
+
+// prefixWriter prefixes each line written to w with the name of the stack
+// it's about. Each Write is passed on to w in a single call, so a syncWriter
+// keeps multi-line writes (e.g. a table of changes) together.
+type prefixWriter struct {
+	prefix  string
+	w       io.Writer
+	midLine bool
+}
+
+func newPrefixWriter(w io.Writer, stackName string) *prefixWriter {
+	return &prefixWriter{prefix: fmt.Sprintf("[%s] ", stackName), w: w}
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	buf := &bytes.Buffer{}
+	for _, line := range bytes.SplitAfter(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if !pw.midLine {
+			buf.WriteString(pw.prefix)
+		}
+		buf.Write(line)
+		pw.midLine = line[len(line)-1] != '\n'
+	}
+
+	_, err := pw.w.Write(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func deploy(cmd *cobra.Command) error {
+	region := viper.GetString("region")
+	profile := viper.GetString("profile")
+	manifestPath, _ := cmd.PersistentFlags().GetString("manifest")
+	concurrency, _ := cmd.PersistentFlags().GetInt("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	m, err := manifest.Read(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	order, err := m.Order()
+	if err != nil {
+		return err
+	}
+
+	sess := awsSession(profile, region)
+	sit := newStackit(sess)
+	sit.SetTemplateUploader(newPackager(sess))
+
+	ctx, end := honey.RootContext()
+	defer end()
+
+	w := &syncWriter{w: cmd.OutOrStderr()}
+
+	results := map[string]*deployResult{}
+	outputs := map[string]map[string]string{}
+	resultch := make(chan *deployResult)
+	confirmMu := &sync.Mutex{}
+
+	pending := order
+	running := 0
+	var firstErr error
+
+	for {
+		for idx := 0; firstErr == nil && running < concurrency && idx < len(pending); {
+			id := pending[idx]
+			if !dependenciesDeployed(m, id, outputs) {
+				idx++
+				continue
+			}
+
+			pending = append(pending[:idx:idx], pending[idx+1:]...)
+
+			params, err := m.ResolveParameters(id, outputs)
+			if err != nil {
+				results[id] = &deployResult{id: id, status: deployStatusFailed, err: err}
+				firstErr = err
+				break
+			}
+
+			running++
+			go func(id string, params map[string]string) {
+				resultch <- deployStack(ctx, cmd, m, id, params, sess, sit, w, confirmMu)
+			}(id, params)
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-resultch
+		running--
+		results[result.id] = result
+
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = result.err
+			}
+		} else {
+			outputs[result.id] = result.outputs
+		}
+	}
+
+	for _, id := range pending {
+		results[id] = &deployResult{id: id, status: deployStatusSkipped}
+	}
+
+	fmt.Fprintln(w, deploySummary(m, order, results))
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	bytes, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling outputs")
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), string(bytes))
+	return nil
+}
+
+func dependenciesDeployed(m *manifest.Manifest, id string, outputs map[string]map[string]string) bool {
+	for _, dep := range m.Dependencies(id) {
+		if _, ok := outputs[dep]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func deployStack(ctx context.Context, cmd *cobra.Command, m *manifest.Manifest, id string, params map[string]string, sess *session.Session, sit *stackit.Stackit, w io.Writer, confirmMu *sync.Mutex) *deployResult {
+	stack := m.Stacks[id]
+	result := &deployResult{id: id, status: deployStatusFailed}
+	start := time.Now()
+	defer func() {
+		result.duration = time.Since(start)
+	}()
+
+	fmt.Fprintf(w, "Deploying %s (stack %s)\n", id, stack.StackName)
+
+	printerCtx, printerCancel := context.WithCancel(ctx)
+	defer printerCancel()
+
+	events := make(chan stackit.TailStackEvent)
+	go printEventsUntilDone(printerCtx, events, stackit.NewPrefixedTailPrinter(mask.Writer(w), stack.StackName))
+
+	// everything else about the stack is prefixed with its name too, as
+	// other stacks may be deployed at the same time
+	out := newPrefixWriter(w, stack.StackName)
+
+	input := stackit.StackitUpInput{
+		StackName:       stack.StackName,
+		RoleARN:         stack.ServiceRole,
+		Parameters:      mergeParameters(nil, params),
+		Tags:            stack.Tags,
+		PopulateMissing: true,
+	}
+
+	if path := m.TemplatePath(id); len(path) > 0 {
+		template, err := pathToTemplate(path)
+		if err != nil {
+			result.err = err
+			return result
+		}
+		input.Template = template
+	} else {
+		input.PreviousTemplate = true
+	}
+
+	prepared, err := prepare(ctx, cmd, input, sess, sit, events, out)
+	if err != nil {
+		result.err = errors.Wrapf(err, "preparing stack %s", stack.StackName)
+		return result
+	}
+
+	if prepared == nil {
+		result.status = deployStatusNoChanges
+	} else {
+		result.changes = len(prepared.Changes)
+
+		confirmMu.Lock()
+		err = confirmChangeSet(cmd, prepared, out)
+		confirmMu.Unlock()
+		if err != nil {
+			result.err = err
+			return result
+		}
+
+		err = sit.Execute(ctx, *prepared.Output.StackId, *prepared.Output.Id, events)
+		if err != nil {
+			result.err = err
+			return result
+		}
+
+		if success, _ := sit.IsSuccessfulState(ctx, *prepared.Output.StackId); !success {
+			result.err = errUnsuccessfulStack
+			return result
+		}
+
+		result.status = deployStatusSucceeded
+	}
+
+	result.outputs, result.err = sit.Outputs(ctx, stack.StackName)
+	if result.err != nil {
+		result.status = deployStatusFailed
+	}
+	return result
+}
+
+func deploySummary(m *manifest.Manifest, order []string, results map[string]*deployResult) string {
+	sbuf := &strings.Builder{}
+	tbl := tablewriter.NewWriter(sbuf)
+	tbl.SetHeader([]string{"Stack", "Result", "Duration", "Changes"})
+
+	for _, id := range order {
+		result := results[id]
+
+		duration, changes := "", ""
+		if result.status != deployStatusSkipped {
+			duration = result.duration.Round(time.Second).String()
+			changes = strconv.Itoa(result.changes)
+		}
+
+		tbl.Append([]string{m.Stacks[id].StackName, result.status, duration, changes})
+	}
+
+	tbl.Render()
+	return sbuf.String()
+}
+
+func init() {
+	deployCmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Bring every stack in a manifest up to date, in dependency order",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := deploy(cmd)
+			if err == errUnsuccessfulStack || err == errChangeSetDeclined {
+				defaultExiter(1)
+			} else if err != nil {
+				panic(err)
+			}
+		},
+	}
+	RootCmd.AddCommand(deployCmd)
+
+	deployCmd.PersistentFlags().String("manifest", "stackit.yaml", "Path to manifest of stacks to deploy")
+	deployCmd.PersistentFlags().Int("concurrency", 1, "Maximum number of independent stacks to deploy at once")
+	deployCmd.PersistentFlags().BoolP("yes", "y", false, "Execute change sets without prompting for confirmation")
+	deployCmd.PersistentFlags().Bool("allow-replacements", false, "Allow non-interactive execution of change sets that replace resources")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/glassechidna/stackit/pkg/stackit/manifest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDeploySummary(t *testing.T) {
+	m, err := manifest.Parse([]byte(`
+stacks:
+  network:
+    stack-name: network-prod
+  database:
+    depends-on: [network]
+  app:
+    depends-on: [database]
+`))
+	assert.NoError(t, err)
+
+	results := map[string]*deployResult{
+		"network":  {id: "network", status: deployStatusSucceeded, duration: 95 * time.Second, changes: 3},
+		"database": {id: "database", status: deployStatusFailed, duration: 12 * time.Second, changes: 1, err: errors.New("boom")},
+		"app":      {id: "app", status: deployStatusSkipped},
+	}
+
+	expected := `+--------------+-----------+----------+---------+
+|    STACK     |  RESULT   | DURATION | CHANGES |
++--------------+-----------+----------+---------+
+| network-prod | SUCCEEDED | 1m35s    |       3 |
+| database     | FAILED    | 12s      |       1 |
+| app          | SKIPPED   |          |         |
++--------------+-----------+----------+---------+
+`
+	assert.Equal(t, expected, deploySummary(m, []string{"network", "database", "app"}, results))
+}
+
+func TestPrefixWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := newPrefixWriter(&syncWriter{w: buf}, "my-stack")
+
+	fmt.Fprint(w, "+---+\n| a |\n+---+\n")
+	fmt.Fprint(w, "Execute change set? [y/N] ")
+	fmt.Fprint(w, "\nChange set cs was not executed\n")
+
+	assert.Equal(t, `[my-stack] +---+
+[my-stack] | a |
+[my-stack] +---+
+[my-stack] Execute change set? [y/N] 
+[my-stack] Change set cs was not executed
+`, buf.String())
+}
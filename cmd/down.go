@@ -15,42 +15,105 @@
 package cmd
 
 import (
-	"context"
+	"fmt"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/glassechidna/stackit/cmd/honey"
 	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var errTerminationProtected = errors.New("stack has termination protection")
+
 var downCmd = &cobra.Command{
 	Use:   "down",
 	Short: "Delete stack",
 	Run: func(cmd *cobra.Command, args []string) {
-		region := viper.GetString("region")
-		profile := viper.GetString("profile")
-		stackName := viper.GetString("stack-name")
+		err := down(cmd)
+		if err == errUnsuccessfulStack || err == errTerminationProtected || err == errTimedOut {
+			defaultExiter(1)
+		} else if err != nil {
+			panic(err)
+		}
+	},
+}
+
+func down(cmd *cobra.Command) error {
+	region := viper.GetString("region")
+	profile := viper.GetString("profile")
+	stackName := viper.GetString("stack-name")
+	forceDisableProtection, _ := cmd.PersistentFlags().GetBool("force-disable-protection")
+
+	sess := awsSession(profile, region)
+	sit := newStackit(sess)
+
+	ctx, end := honey.RootContext()
+	defer end()
 
-		events := make(chan stackit.TailStackEvent)
+	ep, err := startEventPrinter(ctx, cmd, tailResources(ctx, sit, stackName, nil))
+	if err != nil {
+		return err
+	}
+	defer ep.Stop()
+	events := ep.Events
 
-		sess := awsSession(profile, region)
-		sit := stackit.NewStackit(cloudformation.New(sess), sts.New(sess))
+	stackId := stackName
+	if stack, _ := sit.Describe(ctx, stackName); stack != nil {
+		stackId = *stack.StackId
+	}
 
-		ctx, end := honey.RootContext()
-		defer end()
+	timeouts := newTimeouts(cmd)
+	downCtx, cancel := timeouts.phase(ctx, phaseDeletion)
+	defer cancel()
 
-		printerCtx, printerCancel := context.WithCancel(ctx)
-		defer printerCancel()
-		go printUntilDone(printerCtx, events, cmd.OutOrStderr())
+	err = sit.Down(downCtx, stackName, events)
+	if _, ok := err.(*stackit.TerminationProtectedError); ok {
+		if !forceDisableProtection {
+			fmt.Fprintf(cmd.OutOrStderr(), "Refusing to delete: %s. Pass --force-disable-protection to disable it first.\n", err)
+			return errTerminationProtected
+		}
 
-		err := sit.Down(ctx, stackName, events)
+		fmt.Fprintf(cmd.OutOrStderr(), "Disabling termination protection on stack %s\n", stackName)
+		err = sit.SetTerminationProtection(ctx, stackName, false)
 		if err != nil {
-			panic(err)
+			return err
 		}
-	},
+
+		err = sit.Down(downCtx, stackName, events)
+	}
+	if timedOut(downCtx) {
+		ep.Stop()
+		reportTimeout(ctx, sit, stackName, phaseDeletion, cmd.OutOrStderr())
+		if jsonOutput(cmd) {
+			ep.Summary(ctx, sit, stackId, false)
+		}
+		return errTimedOut
+	}
+	if err != nil {
+		return err
+	}
+
+	stack, _ := sit.Describe(ctx, stackId)
+	success := stack == nil || *stack.StackStatus == cloudformation.StackStatusDeleteComplete
+	if !success {
+		ep.Stop()
+		reportFailure(ctx, sit, stackId, cmd.OutOrStderr())
+	}
+
+	if jsonOutput(cmd) {
+		ep.Summary(ctx, sit, stackId, success)
+	}
+
+	if !success {
+		return errUnsuccessfulStack
+	}
+	return nil
 }
 
 func init() {
 	RootCmd.AddCommand(downCmd)
+	addEventFlags(downCmd)
+	addTimeoutFlags(downCmd, phaseDeletion)
+	downCmd.PersistentFlags().Bool("force-disable-protection", false, "Disable termination protection before deleting a protected stack")
 }
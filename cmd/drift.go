@@ -0,0 +1,108 @@
+// Copyright © 2017 Aidan Steele <aidan.steele@glassechidna.com.au>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+var errDriftDetected = errors.New("stack has drifted")
+
+func drift(cmd *cobra.Command) error {
+	region := viper.GetString("region")
+	profile := viper.GetString("profile")
+	stackName := viper.GetString("stack-name")
+	output, _ := cmd.PersistentFlags().GetString("output")
+
+	if output != "table" && output != "json" {
+		return errors.Errorf("unknown output format %s, expected table or json", output)
+	}
+
+	sess := awsSession(profile, region)
+	sit := newStackit(sess)
+
+	ctx, end := honey.RootContext()
+	defer end()
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Detecting drift on stack %s\n", stackName)
+	report, err := sit.DetectDrift(ctx, stackName)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		bytes, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshalling drift report")
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(bytes))
+	} else if report.HasDrift() {
+		fmt.Fprint(cmd.OutOrStdout(), driftTable(report.Resources))
+	} else {
+		fmt.Fprintf(cmd.OutOrStderr(), "Stack %s is in sync\n", stackName)
+	}
+
+	if report.HasDrift() {
+		return errDriftDetected
+	}
+
+	return nil
+}
+
+func driftTable(resources []stackit.ResourceDrift) string {
+	sbuf := &strings.Builder{}
+	tbl := tablewriter.NewWriter(sbuf)
+	tbl.SetHeader([]string{"Resource", "Type", "Drift", "Property", "Expected", "Actual"})
+
+	for _, resource := range resources {
+		if len(resource.Differences) == 0 {
+			tbl.Append([]string{resource.LogicalId, resource.Type, resource.Status, "", "", ""})
+			continue
+		}
+
+		for _, diff := range resource.Differences {
+			tbl.Append([]string{resource.LogicalId, resource.Type, resource.Status, diff.Path, diff.Expected, diff.Actual})
+		}
+	}
+
+	tbl.Render()
+	return sbuf.String()
+}
+
+func init() {
+	driftCmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report resources that have drifted from the stack's template",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := drift(cmd)
+			if err == errDriftDetected {
+				defaultExiter(1)
+			} else if err != nil {
+				panic(err)
+			}
+		},
+	}
+	RootCmd.AddCommand(driftCmd)
+
+	driftCmd.PersistentFlags().String("output", "table", "Output format, either table or json")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDriftTable(t *testing.T) {
+	report := &stackit.DriftReport{
+		StackName: "stack-name",
+		Status:    "DRIFTED",
+		Resources: []stackit.ResourceDrift{
+			{
+				LogicalId: "Queue",
+				Type:      "AWS::SQS::Queue",
+				Status:    "MODIFIED",
+				Differences: []stackit.PropertyDifference{
+					{Path: "/VisibilityTimeout", Type: "NOT_EQUAL", Expected: "30", Actual: "60"},
+				},
+			},
+			{LogicalId: "Topic", Type: "AWS::SNS::Topic", Status: "DELETED"},
+		},
+	}
+
+	expected := `
++----------+-----------------+----------+--------------------+----------+--------+
+| RESOURCE |      TYPE       |  DRIFT   |      PROPERTY      | EXPECTED | ACTUAL |
++----------+-----------------+----------+--------------------+----------+--------+
+| Queue    | AWS::SQS::Queue | MODIFIED | /VisibilityTimeout |       30 |     60 |
+| Topic    | AWS::SNS::Topic | DELETED  |                    |          |        |
++----------+-----------------+----------+--------------------+----------+--------+
+`[1:]
+	assert.Equal(t, expected, driftTable(report.Resources))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/glassechidna/stackit/cmd/mask"
 	"github.com/honeycombio/beeline-go"
 	"github.com/honeycombio/beeline-go/trace"
 	"os"
@@ -53,18 +54,29 @@ func TryAdd(sess *session.Session) {
 	})
 }
 
+// sensitiveOutputOperations return secret values, which can't have been
+// registered with the mask package yet when their spans are completed.
+var sensitiveOutputOperations = map[string]bool{
+	"GetSecretValue":      true,
+	"GetParameter":        true,
+	"GetParameters":       true,
+	"GetParametersByPath": true,
+}
+
 func honeycombStartAws(r *request.Request) {
 	name := fmt.Sprintf("%s %s", r.ClientInfo.ServiceName, r.Operation.Name)
 	ctx, span := beeline.StartSpan(r.Context(), name)
 	span.AddField("aws.service", r.ClientInfo.ServiceName)
 	span.AddField("aws.action", r.Operation.Name)
-	span.AddField("aws.input", r.Params)
+	span.AddField("aws.input", mask.Value(r.Params))
 	r.SetContext(ctx)
 }
 
 func honeycombCompleteAws(r *request.Request) {
 	span := trace.GetSpanFromContext(r.Context())
-	span.AddField("aws.output", r.Data)
+	if !sensitiveOutputOperations[r.Operation.Name] {
+		span.AddField("aws.output", mask.Value(r.Data))
+	}
 
 	if awsErr, ok := r.Error.(awserr.Error); ok {
 		span.AddField("aws.error.code", awsErr.Code())
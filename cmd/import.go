@@ -0,0 +1,124 @@
+// Copyright © 2017 Aidan Steele <aidan.steele@glassechidna.com.au>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io/ioutil"
+	"sort"
+)
+
+func importResources(cmd *cobra.Command, args []string) error {
+	mappingPath, _ := cmd.PersistentFlags().GetString("mapping")
+
+	input, err := parseCLIInput(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	if input.Template == nil {
+		return errors.New("import requires a --template containing the resources to import")
+	}
+
+	input.ResourcesToImport, err = readImportMapping(mappingPath)
+	if err != nil {
+		return err
+	}
+
+	return upWithInput(cmd, input)
+}
+
+func readImportMapping(path string) ([]stackit.ResourceToImport, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading import mapping file")
+	}
+
+	resources, err := parseImportMapping(body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing import mapping file %s", path)
+	}
+
+	return resources, nil
+}
+
+// parseImportMapping parses a map of logical IDs to either the physical ID of
+// the resource or a map of its identifier properties, e.g.
+//
+//	Bucket: my-bucket
+//	Table:
+//	  TableName: my-table
+func parseImportMapping(body []byte) ([]stackit.ResourceToImport, error) {
+	doc, err := unmarshalYAML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, ok := doc.(map[string]interface{})
+	if !ok || len(mapping) == 0 {
+		return nil, errors.New("expected a map of logical IDs to physical identifiers")
+	}
+
+	resources := []stackit.ResourceToImport{}
+	for logicalId, value := range mapping {
+		resource := stackit.ResourceToImport{LogicalId: logicalId}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			resource.Identifier = map[string]string{}
+			for key, val := range value {
+				resource.Identifier[key] = parameterValueString(val)
+			}
+		case []interface{}, nil:
+			return nil, errors.Errorf("identifier for %s must be a string or a map of identifier properties", logicalId)
+		default:
+			resource.PhysicalId = parameterValueString(value)
+		}
+
+		resources = append(resources, resource)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].LogicalId < resources[j].LogicalId
+	})
+
+	return resources, nil
+}
+
+func init() {
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Bring existing resources under management of a stack",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := importResources(cmd, args)
+			if err == errUnsuccessfulStack || err == errChangeSetDeclined || err == errDriftDetected || err == errTimedOut {
+				defaultExiter(1)
+			} else if err != nil {
+				panic(err)
+			}
+		},
+	}
+	RootCmd.AddCommand(importCmd)
+
+	addPrepareFlags(importCmd)
+	addEventFlags(importCmd)
+	importCmd.PersistentFlags().String("mapping", "", "Path to file mapping logical IDs to physical identifiers of resources to import")
+	importCmd.PersistentFlags().BoolP("yes", "y", false, "Execute change set without prompting for confirmation")
+	importCmd.PersistentFlags().Bool("allow-replacements", false, "Allow non-interactive execution of change sets that replace resources")
+	addTimeoutFlags(importCmd, phaseChangeSet, phaseExecution)
+	importCmd.PersistentFlags().Bool("cancel-on-timeout", false, "Cancel the import if execution times out")
+}
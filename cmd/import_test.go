@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseImportMapping(t *testing.T) {
+	resources, err := parseImportMapping([]byte(`
+Bucket: my-bucket
+Table:
+  TableName: my-table
+Account:
+  AccountId: 012345678901
+Version: 1.10
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []stackit.ResourceToImport{
+		{LogicalId: "Account", Identifier: map[string]string{"AccountId": "012345678901"}},
+		{LogicalId: "Bucket", PhysicalId: "my-bucket"},
+		{LogicalId: "Table", Identifier: map[string]string{"TableName": "my-table"}},
+		{LogicalId: "Version", PhysicalId: "1.10"},
+	}, resources)
+
+	_, err = parseImportMapping([]byte(`- Bucket`))
+	assert.EqualError(t, err, "expected a map of logical IDs to physical identifiers")
+}
@@ -0,0 +1,106 @@
+package mask
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+const Mask = "****"
+
+var (
+	mu      sync.RWMutex
+	secrets []string
+)
+
+// Add registers a secret value that should never appear in output. The forms
+// it takes when escaped in JSON strings are registered too, as JSON output is
+// masked after it has been marshalled, e.g. p&ss is written as p\u0026ss.
+func Add(secret string) {
+	if len(secret) == 0 {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, escaped := range jsonEscaped(secret) {
+		if escaped != secret {
+			secrets = append(secrets, escaped)
+		}
+	}
+	secrets = append(secrets, secret)
+}
+
+// jsonEscaped returns the secret as it appears inside JSON strings, both with
+// and without HTML characters escaped.
+func jsonEscaped(secret string) []string {
+	escaped := []string{}
+
+	for _, escapeHTML := range []bool{true, false} {
+		buf := &bytes.Buffer{}
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(escapeHTML)
+		if err := encoder.Encode(secret); err != nil {
+			continue
+		}
+
+		quoted := strings.TrimSuffix(buf.String(), "\n")
+		escaped = append(escaped, quoted[1:len(quoted)-1])
+	}
+
+	return escaped
+}
+
+func Active() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return len(secrets) > 0
+}
+
+// String replaces every registered secret in s with Mask.
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, Mask, -1)
+	}
+	return s
+}
+
+// Value returns v unchanged if no secrets are registered, otherwise its JSON
+// representation with every registered secret replaced.
+func Value(v interface{}) interface{} {
+	if !Active() {
+		return v
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return Mask
+	}
+
+	return String(string(body))
+}
+
+type writer struct {
+	w io.Writer
+}
+
+// Writer returns a writer that masks registered secrets before writing to w.
+// Each call to Write should contain whole lines, as secrets split across
+// writes won't be masked.
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+func (mw *writer) Write(p []byte) (int, error) {
+	_, err := io.WriteString(mw.w, String(string(p)))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
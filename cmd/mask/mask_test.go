@@ -0,0 +1,34 @@
+package mask
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, map[string]int{"a": 1}, Value(map[string]int{"a": 1}))
+
+	Add("hunter2")
+	Add("")
+
+	assert.Equal(t, "password is ****, really ****", String("password is hunter2, really hunter2"))
+	assert.Equal(t, `{"Password":"****"}`, Value(struct{ Password string }{"hunter2"}))
+
+	buf := &bytes.Buffer{}
+	n, err := Writer(buf).Write([]byte("hunter2\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, 8, n)
+	assert.Equal(t, "****\n", buf.String())
+
+	Add(`p&ss"w<rd`)
+
+	assert.Equal(t, "password is ****", String(`password is p&ss"w<rd`))
+	assert.Equal(t, `{"Password":"****"}`, Value(struct{ Password string }{`p&ss"w<rd`}))
+	assert.Equal(t, `{"Params":{"Password":"****"}}`, Value(map[string]interface{}{"Params": map[string]string{"Password": `p&ss"w<rd`}}))
+
+	buf = &bytes.Buffer{}
+	_, err = Writer(buf).Write([]byte(`{"reason":"p\u0026ss\"w\u003crd"} {"reason":"p&ss\"w<rd"}` + "\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"reason":"****"} {"reason":"****"}`+"\n", buf.String())
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/glassechidna/stackit/cmd/mask"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/glassechidna/stackit/pkg/stackit/cfnyaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	outputText = "text"
+	outputJson = "json"
+)
+
+func addEventFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("output", outputText, "Format of stack events, either text or json (newline-delimited)")
+	cmd.PersistentFlags().Bool("show-resource-type", false, "Show the type of each resource in stack events")
+	cmd.PersistentFlags().Bool("show-physical-id", false, "Show the physical ID of each resource in stack events")
+	cmd.PersistentFlags().Bool("dashboard", false, "Show a live table of resource statuses instead of stack events when stderr is a terminal")
+}
+
+// newTailPrinter returns a printer for the format chosen with --output.
+// Text is printed to stderr, whereas JSON is printed to stdout so that it
+// can be piped to other programs. resources are used to size the columns of
+// text output.
+func newTailPrinter(cmd *cobra.Command, resources map[string]string) (stackit.TailPrinter, error) {
+	output, _ := cmd.PersistentFlags().GetString("output")
+
+	switch output {
+	case "", outputText:
+		showResourceType, _ := cmd.PersistentFlags().GetBool("show-resource-type")
+		showPhysicalId, _ := cmd.PersistentFlags().GetBool("show-physical-id")
+
+		width, _ := terminalSize(cmd.OutOrStderr())
+		printer := stackit.NewTailPrinter(mask.Writer(cmd.OutOrStderr()))
+		printer.FitResources(resources)
+		printer.SetOptions(stackit.TailPrinterOptions{
+			ResourceType: showResourceType,
+			PhysicalId:   showPhysicalId,
+			Width:        width,
+		})
+		return printer, nil
+	case outputJson:
+		return stackit.NewJsonTailPrinter(mask.Writer(cmd.OutOrStdout())), nil
+	default:
+		return stackit.TailPrinter{}, errors.Errorf("unknown output format %s, expected text or json", output)
+	}
+}
+
+// newDashboard returns a dashboard if one was asked for with --dashboard and
+// text is being printed to a terminal tall enough for one, or nil if events
+// should be printed line by line instead.
+func newDashboard(cmd *cobra.Command, resources map[string]string) *stackit.Dashboard {
+	dashboard, _ := cmd.PersistentFlags().GetBool("dashboard")
+	width, height := terminalSize(cmd.OutOrStderr())
+	if !dashboard || jsonOutput(cmd) || width == 0 || height < stackit.MinDashboardHeight {
+		return nil
+	}
+
+	return stackit.NewDashboard(mask.Writer(cmd.OutOrStderr()), resources, width, height)
+}
+
+func jsonOutput(cmd *cobra.Command) bool {
+	output, _ := cmd.PersistentFlags().GetString("output")
+	return output == outputJson
+}
+
+// tailResources returns the resources whose events may be printed while
+// operating on the stack: those in the template, if given, and those
+// currently in the stack. Errors are ignored, as the resources only affect
+// the layout of events.
+func tailResources(ctx context.Context, sit *stackit.Stackit, stackName string, template stackit.Template) map[string]string {
+	resources := templateResources(stackName, template)
+
+	if stack, _ := sit.Describe(ctx, stackName); stack != nil {
+		existing, _ := sit.ResourceTypes(ctx, *stack.StackId)
+		for name, typ := range existing {
+			if _, ok := resources[name]; !ok {
+				resources[name] = typ
+			}
+		}
+	}
+
+	return resources
+}
+
+// templateResources returns the stack itself and the resources in the
+// template, if given, keyed by logical ID.
+func templateResources(stackName string, template stackit.Template) map[string]string {
+	resources := map[string]string{stackName: "AWS::CloudFormation::Stack"}
+
+	if template != nil {
+		if parsed, err := cfnyaml.Parse([]byte(template.String())); err == nil {
+			types, _ := parsed.ResourceTypes()
+			for name, typ := range types {
+				resources[name] = typ
+			}
+		}
+	}
+
+	return resources
+}
+
+// eventPrinter prints the events sent to Events in the background until it
+// is stopped.
+type eventPrinter struct {
+	Events  chan stackit.TailStackEvent
+	printer stackit.TailPrinter
+	cancel  context.CancelFunc
+	done    chan struct{}
+	once    sync.Once
+}
+
+func startEventPrinter(ctx context.Context, cmd *cobra.Command, resources map[string]string) (*eventPrinter, error) {
+	printer, err := newTailPrinter(cmd, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	printerCtx, cancel := context.WithCancel(ctx)
+	ep := &eventPrinter{
+		Events:  make(chan stackit.TailStackEvent),
+		printer: printer,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	dashboard := newDashboard(cmd, resources)
+
+	go func() {
+		defer close(ep.done)
+		if dashboard != nil {
+			drawDashboardUntilDone(printerCtx, ep.Events, dashboard)
+			return
+		}
+
+		for {
+			select {
+			case tailEvent := <-ep.Events:
+				printer.PrintTailEvent(tailEvent)
+			case <-printerCtx.Done():
+				return
+			}
+		}
+	}()
+
+	return ep, nil
+}
+
+func drawDashboardUntilDone(ctx context.Context, events <-chan stackit.TailStackEvent, dashboard *stackit.Dashboard) {
+	tick := time.NewTicker(100 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		select {
+		case tailEvent := <-events:
+			dashboard.Update(tailEvent)
+		case <-tick.C:
+			dashboard.Draw(time.Now())
+		case <-ctx.Done():
+			dashboard.Draw(time.Now())
+			return
+		}
+	}
+}
+
+// Stop waits for the event being printed, if any, and stops printing.
+func (ep *eventPrinter) Stop() {
+	ep.once.Do(func() {
+		ep.cancel()
+		<-ep.done
+	})
+}
+
+// reportFailure explains an unsuccessful operation on the stack by listing
+// the resources that failed first and how the rollback turned out.
+func reportFailure(ctx context.Context, sit *stackit.Stackit, stackId string, w io.Writer) {
+	summary, err := sit.FailureSummary(ctx, stackId)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s", mask.String(summary.String()))
+}
+
+// Summary stops printing events and prints a summary of the stack's final
+// state.
+func (ep *eventPrinter) Summary(ctx context.Context, sit *stackit.Stackit, stackName string, success bool) {
+	ep.Stop()
+	printSummary(ctx, sit, ep.printer, stackName, success)
+}
+
+// printSummary prints a summary of the stack's final state. Stack outputs
+// are included if the operation was successful.
+func printSummary(ctx context.Context, sit *stackit.Stackit, printer stackit.TailPrinter, stackName string, success bool) {
+	summary := stackit.TailSummaryRecord{StackName: stackName, Success: success}
+
+	stack, _ := sit.Describe(ctx, stackName)
+	if stack != nil {
+		summary.StackName = *stack.StackName
+		summary.StackId = *stack.StackId
+		summary.Status = *stack.StackStatus
+
+		if success {
+			summary.Outputs = map[string]string{}
+			for _, output := range stack.Outputs {
+				summary.Outputs[*output.OutputKey] = *output.OutputValue
+			}
+		} else {
+			summary.Failure, _ = sit.FailureSummary(ctx, *stack.StackId)
+		}
+	}
+
+	printer.PrintSummary(summary)
+}
@@ -15,10 +15,7 @@
 package cmd
 
 import (
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/glassechidna/stackit/cmd/honey"
-	"github.com/glassechidna/stackit/pkg/stackit"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -32,7 +29,7 @@ var outputsCmd = &cobra.Command{
 		stackName := viper.GetString("stack-name")
 
 		sess := awsSession(profile, region)
-		sit := stackit.NewStackit(cloudformation.New(sess), sts.New(sess))
+		sit := newStackit(sess)
 
 		ctx, end := honey.RootContext()
 		defer end()
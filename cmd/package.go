@@ -18,10 +18,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/cmd/mask"
 	"github.com/glassechidna/stackit/pkg/stackit"
 	"github.com/glassechidna/stackit/pkg/stackit/packager"
 	"github.com/olekukonko/tablewriter"
@@ -35,9 +37,13 @@ import (
 	"text/template"
 )
 
-func packageTemplate(ctx context.Context, sess *session.Session, prefix string, templateReader packager.TemplateReader, writer io.Writer) (*string, error) {
+func newPackager(sess *session.Session) *packager.Packager {
 	s3api := s3.New(sess)
-	pkger := packager.New(s3api, sts.New(sess), *s3api.Config.Region)
+	return packager.New(s3api, sts.New(sess), *s3api.Config.Region)
+}
+
+func packageTemplate(ctx context.Context, sess *session.Session, prefix string, templateReader packager.TemplateReader, writer io.Writer) (*string, error) {
+	pkger := newPackager(sess)
 	packagedTemplate, err := pkger.Package(ctx, prefix, templateReader, writer)
 	if err != nil {
 		return nil, errors.Wrap(err, "packaging template")
@@ -62,13 +68,20 @@ func writePackagedTemplateFile(absPath string, packagedTemplate *string, writer
 func userFriendlyChangesOutput(output *stackit.PrepareOutput) string {
 	sbuf := &strings.Builder{}
 	tbl := tablewriter.NewWriter(sbuf)
-	tbl.SetHeader([]string{"Action", "Resource", "Type"})
+	tbl.SetHeader([]string{"Action", "Resource", "Type", "Replacement", "Scope"})
 
 	for _, change := range output.Changes {
+		replacement := ""
+		if change.ResourceChange.Replacement != nil {
+			replacement = *change.ResourceChange.Replacement
+		}
+
 		tbl.Append([]string{
 			*change.ResourceChange.Action,
 			*change.ResourceChange.LogicalResourceId,
 			*change.ResourceChange.ResourceType,
+			replacement,
+			strings.Join(aws.StringValueSlice(change.ResourceChange.Scope), ", "),
 		})
 	}
 
@@ -92,7 +105,7 @@ Changes:
 		"Changes":     sbuf.String(),
 	})
 
-	return buf.String()
+	return mask.String(buf.String())
 }
 
 func init() {
@@ -91,12 +91,12 @@ Stack ID: arn:aws:cloudformation:ap-southeast-2:720884384464:stack/aidan-mtd-tes
 Change Set ID: arn:aws:cloudformation:ap-southeast-2:720884384464:changeSet/aidan-mtd-test-csid-1557355052/dc7928df-d27e-4992-a350-9ee4ba357999
 Changes:
 
-+--------+-----------------------+----------------------+
-| ACTION |       RESOURCE        |         TYPE         |
-+--------+-----------------------+----------------------+
-| Add    | Cell                  | Custom::XeroCellInfo |
-| Add    | CodeDeployServiceRole | AWS::IAM::Role       |
-+--------+-----------------------+----------------------+
++--------+-----------------------+----------------------+-------------+-------+
+| ACTION |       RESOURCE        |         TYPE         | REPLACEMENT | SCOPE |
++--------+-----------------------+----------------------+-------------+-------+
+| Add    | Cell                  | Custom::XeroCellInfo |             |       |
+| Add    | CodeDeployServiceRole | AWS::IAM::Role       |             |       |
++--------+-----------------------+----------------------+-------------+-------+
 `
 	assert.Equal(t, expected, userFriendlyChangesOutput(&input))
 }
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// parametersFile is the common representation of the parameter file formats
+// accepted by --parameters-file:
+//
+// * the AWS CLI's [{"ParameterKey": "...", "ParameterValue": "..."}] list
+// * a flat map of parameter names to values, in YAML or JSON
+// * CodePipeline's template configuration {"Parameters": {}, "Tags": {}} file
+type parametersFile struct {
+	Parameters  map[string]*cloudformation.Parameter
+	Tags        map[string]string
+	StackPolicy string
+}
+
+func readParametersFile(path string) (*parametersFile, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading parameters file")
+	}
+
+	pf, err := parseParametersFile(body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing parameters file %s", path)
+	}
+
+	return pf, nil
+}
+
+func parseParametersFile(body []byte) (*parametersFile, error) {
+	doc, err := unmarshalYAML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &parametersFile{
+		Parameters: map[string]*cloudformation.Parameter{},
+		Tags:       map[string]string{},
+	}
+
+	switch doc := doc.(type) {
+	case nil:
+		return pf, nil
+	case []interface{}:
+		return pf, parseCliParameters(doc, pf)
+	case map[string]interface{}:
+		if isTemplateConfiguration(doc) {
+			return pf, parseTemplateConfiguration(doc, pf)
+		}
+
+		for key, value := range doc {
+			pf.Parameters[key] = &cloudformation.Parameter{
+				ParameterKey:   aws.String(key),
+				ParameterValue: aws.String(parameterValueString(value)),
+			}
+		}
+		return pf, nil
+	default:
+		return nil, errors.New("expected a list or map of parameters")
+	}
+}
+
+func parseCliParameters(doc []interface{}, pf *parametersFile) error {
+	for idx, item := range doc {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("parameter %d is not a map", idx)
+		}
+
+		key, _ := entry["ParameterKey"].(string)
+		if key == "" {
+			return errors.Errorf("parameter %d has no ParameterKey", idx)
+		}
+
+		param := &cloudformation.Parameter{ParameterKey: aws.String(key)}
+		if usePrevious, _ := entry["UsePreviousValue"].(bool); usePrevious {
+			param.UsePreviousValue = aws.Bool(true)
+		} else {
+			param.ParameterValue = aws.String(parameterValueString(entry["ParameterValue"]))
+		}
+
+		pf.Parameters[key] = param
+	}
+
+	return nil
+}
+
+func isTemplateConfiguration(doc map[string]interface{}) bool {
+	if _, ok := doc["Parameters"].(map[string]interface{}); !ok {
+		return false
+	}
+
+	for key := range doc {
+		if key != "Parameters" && key != "Tags" && key != "StackPolicy" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseTemplateConfiguration(doc map[string]interface{}, pf *parametersFile) error {
+	for key, value := range doc["Parameters"].(map[string]interface{}) {
+		pf.Parameters[key] = &cloudformation.Parameter{
+			ParameterKey:   aws.String(key),
+			ParameterValue: aws.String(parameterValueString(value)),
+		}
+	}
+
+	if tags, ok := doc["Tags"]; ok {
+		tagMap, ok := tags.(map[string]interface{})
+		if !ok {
+			return errors.New("Tags must be a map")
+		}
+
+		for key, value := range tagMap {
+			pf.Tags[key] = parameterValueString(value)
+		}
+	}
+
+	if policy, ok := doc["StackPolicy"]; ok {
+		body, err := json.Marshal(policy)
+		if err != nil {
+			return errors.Wrap(err, "marshalling StackPolicy")
+		}
+		pf.StackPolicy = string(body)
+	}
+
+	return nil
+}
+
+// yamlNumber is a number in a YAML document. Values are sent to AWS as they
+// were written, so the number's text is kept alongside its value: otherwise
+// an account ID like 012345678901 would be sent as 1.2345678901e+10.
+type yamlNumber struct {
+	text  string
+	value interface{}
+}
+
+func (n yamlNumber) String() string {
+	return n.text
+}
+
+func (n yamlNumber) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.value)
+}
+
+// unmarshalYAML decodes a YAML (or JSON) document into maps with string
+// keys, slices and scalars like yaml.Unmarshal into an interface{} does,
+// except that numbers are decoded as yamlNumbers.
+func unmarshalYAML(body []byte) (interface{}, error) {
+	node := &yaml.Node{}
+	err := yaml.Unmarshal(body, node)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	return yamlValue(node.Content[0])
+}
+
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		m := map[string]interface{}{}
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			value, err := yamlValue(node.Content[idx+1])
+			if err != nil {
+				return nil, err
+			}
+			m[node.Content[idx].Value] = value
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		s := make([]interface{}, len(node.Content))
+		for idx, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			s[idx] = value
+		}
+		return s, nil
+	}
+
+	var value interface{}
+	err := node.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.ShortTag() {
+	case "!!int", "!!float":
+		return yamlNumber{text: node.Value, value: value}, nil
+	default:
+		return value, nil
+	}
+}
+
+func parameterValueString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []interface{}:
+		strs := []string{}
+		for _, item := range value {
+			strs = append(strs, parameterValueString(item))
+		}
+		return strings.Join(strs, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// mergeParameters combines parameter files (later files taking precedence
+// over earlier ones) with key-value pairs passed on the command line, which
+// take precedence over all files.
+func mergeParameters(files []*parametersFile, cliParams map[string]string) []*cloudformation.Parameter {
+	merged := map[string]*cloudformation.Parameter{}
+
+	for _, pf := range files {
+		for key, param := range pf.Parameters {
+			merged[key] = param
+		}
+	}
+
+	for name, value := range cliParams {
+		merged[name] = &cloudformation.Parameter{
+			ParameterKey:   aws.String(name),
+			ParameterValue: aws.String(value),
+		}
+	}
+
+	names := []string{}
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := []*cloudformation.Parameter{}
+	for _, name := range names {
+		params = append(params, merged[name])
+	}
+
+	return params
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseParametersFile(t *testing.T) {
+	t.Run("aws cli json", func(t *testing.T) {
+		pf, err := parseParametersFile([]byte(`[
+  {"ParameterKey": "Env", "ParameterValue": "prod"},
+  {"ParameterKey": "Image", "UsePreviousValue": true}
+]`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*cloudformation.Parameter{
+			"Env":   {ParameterKey: aws.String("Env"), ParameterValue: aws.String("prod")},
+			"Image": {ParameterKey: aws.String("Image"), UsePreviousValue: aws.Bool(true)},
+		}, pf.Parameters)
+	})
+
+	t.Run("flat yaml", func(t *testing.T) {
+		pf, err := parseParametersFile([]byte(`
+Env: prod
+Count: 3
+Subnets: [subnet-a, subnet-b]
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*cloudformation.Parameter{
+			"Env":     {ParameterKey: aws.String("Env"), ParameterValue: aws.String("prod")},
+			"Count":   {ParameterKey: aws.String("Count"), ParameterValue: aws.String("3")},
+			"Subnets": {ParameterKey: aws.String("Subnets"), ParameterValue: aws.String("subnet-a,subnet-b")},
+		}, pf.Parameters)
+	})
+
+	t.Run("numbers as written", func(t *testing.T) {
+		pf, err := parseParametersFile([]byte(`
+AccountId: 012345678901
+Version: 1.10
+Size: 1e3
+Ports: [080, 443]
+`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*cloudformation.Parameter{
+			"AccountId": {ParameterKey: aws.String("AccountId"), ParameterValue: aws.String("012345678901")},
+			"Version":   {ParameterKey: aws.String("Version"), ParameterValue: aws.String("1.10")},
+			"Size":      {ParameterKey: aws.String("Size"), ParameterValue: aws.String("1e3")},
+			"Ports":     {ParameterKey: aws.String("Ports"), ParameterValue: aws.String("080,443")},
+		}, pf.Parameters)
+	})
+
+	t.Run("codepipeline template configuration", func(t *testing.T) {
+		pf, err := parseParametersFile([]byte(`{
+  "Parameters": {"Env": "prod"},
+  "Tags": {"team": "platform", "cost-centre": 0012},
+  "StackPolicy": {"Statement": [{"Effect": "Allow", "Action": "Update:*", "Principal": "*", "Resource": "*", "Condition": {"NumericLessThan": {"aws:MultiFactorAuthAge": 3600}}}]}
+}`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]*cloudformation.Parameter{
+			"Env": {ParameterKey: aws.String("Env"), ParameterValue: aws.String("prod")},
+		}, pf.Parameters)
+		assert.Equal(t, map[string]string{"team": "platform", "cost-centre": "0012"}, pf.Tags)
+		assert.JSONEq(t, `{"Statement": [{"Effect": "Allow", "Action": "Update:*", "Principal": "*", "Resource": "*", "Condition": {"NumericLessThan": {"aws:MultiFactorAuthAge": 3600}}}]}`, pf.StackPolicy)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := parseParametersFile([]byte(`[{"ParameterValue": "prod"}]`))
+		assert.EqualError(t, err, "parameter 0 has no ParameterKey")
+	})
+}
+
+func TestMergeParameters(t *testing.T) {
+	first, _ := parseParametersFile([]byte(`{"Env": "dev", "Count": "1"}`))
+	second, _ := parseParametersFile([]byte(`{"Env": "prod", "Image": "nginx"}`))
+
+	params := mergeParameters([]*parametersFile{first, second}, map[string]string{"Image": "redis"})
+	assert.Equal(t, []*cloudformation.Parameter{
+		{ParameterKey: aws.String("Count"), ParameterValue: aws.String("1")},
+		{ParameterKey: aws.String("Env"), ParameterValue: aws.String("prod")},
+		{ParameterKey: aws.String("Image"), ParameterValue: aws.String("redis")},
+	}, params)
+}
@@ -0,0 +1,169 @@
+// Copyright © 2017 Aidan Steele <aidan.steele@glassechidna.com.au>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/cmd/mask"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"io/ioutil"
+)
+
+const defaultPlanFile = "stackit-plan.json"
+
+func plan(cmd *cobra.Command, args []string) error {
+	region := viper.GetString("region")
+	profile := viper.GetString("profile")
+	planFile, _ := cmd.PersistentFlags().GetString("plan-file")
+	input, err := parseCLIInput(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	sess := awsSession(profile, region)
+	sit := newStackit(sess)
+	sit.SetTemplateUploader(newPackager(sess))
+
+	ctx, end := honey.RootContext()
+	defer end()
+
+	printerCtx, printerCancel := context.WithCancel(ctx)
+	defer printerCancel()
+
+	events := make(chan stackit.TailStackEvent)
+	go printUntilDone(printerCtx, events, cmd.OutOrStderr())
+
+	prepared, err := prepare(ctx, cmd, input, sess, sit, events, cmd.OutOrStderr())
+	if err != nil {
+		return err
+	}
+
+	p, err := sit.NewPlan(ctx, input, prepared)
+	if err != nil {
+		return errors.Wrap(err, "creating plan")
+	}
+
+	if p.HasChanges() {
+		fmt.Fprintln(cmd.OutOrStderr(), userFriendlyChangesOutput(prepared))
+	} else {
+		fmt.Fprintf(cmd.OutOrStderr(), "No changes to stack %s\n", input.StackName)
+	}
+
+	body, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling plan")
+	}
+
+	err = ioutil.WriteFile(planFile, []byte(mask.String(string(body))), 0644)
+	if err != nil {
+		return errors.Wrap(err, "writing plan file")
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Wrote plan to %s\n", planFile)
+	return nil
+}
+
+func apply(cmd *cobra.Command) error {
+	region := viper.GetString("region")
+	profile := viper.GetString("profile")
+	planFile, _ := cmd.PersistentFlags().GetString("plan-file")
+
+	body, err := ioutil.ReadFile(planFile)
+	if err != nil {
+		return errors.Wrap(err, "reading plan file")
+	}
+
+	p := &stackit.Plan{}
+	err = json.Unmarshal(body, p)
+	if err != nil {
+		return errors.Wrap(err, "parsing plan file")
+	}
+
+	if !p.HasChanges() {
+		fmt.Fprintf(cmd.OutOrStderr(), "Plan contains no changes to stack %s\n", p.StackName)
+		return nil
+	}
+
+	sess := awsSession(profile, region)
+	sit := newStackit(sess)
+
+	ctx, end := honey.RootContext()
+	defer end()
+
+	err = sit.VerifyPlan(ctx, p)
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "Refusing to apply plan: %s\n", err)
+		return errChangeSetDeclined
+	}
+
+	printerCtx, printerCancel := context.WithCancel(ctx)
+	defer printerCancel()
+
+	events := make(chan stackit.TailStackEvent)
+	go printUntilDone(printerCtx, events, cmd.OutOrStderr())
+
+	err = sit.ExecuteWithStackPolicy(ctx, p.StackId, p.ChangeSetId, p.StackPolicyBody, p.StackPolicyDuringUpdateBody, events)
+	if err != nil {
+		return err
+	}
+
+	if success, _ := sit.IsSuccessfulState(ctx, p.StackId); !success {
+		return errUnsuccessfulStack
+	}
+
+	sit.PrintOutputs(ctx, p.StackId, cmd.OutOrStdout())
+	return nil
+}
+
+func init() {
+	planCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Create a change set and save it to a plan file for later review",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := plan(cmd, args)
+			if err == errUnsuccessfulStack || err == errChangeSetDeclined || err == errDriftDetected {
+				defaultExiter(1)
+			} else if err != nil {
+				panic(err)
+			}
+		},
+	}
+	RootCmd.AddCommand(planCmd)
+
+	addPrepareFlags(planCmd)
+	planCmd.PersistentFlags().String("plan-file", defaultPlanFile, "Path to write plan to")
+
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Execute the change set in a previously saved plan file",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := apply(cmd)
+			if err == errUnsuccessfulStack || err == errChangeSetDeclined {
+				defaultExiter(1)
+			} else if err != nil {
+				panic(err)
+			}
+		},
+	}
+	RootCmd.AddCommand(applyCmd)
+
+	applyCmd.PersistentFlags().String("plan-file", defaultPlanFile, "Path to read plan from")
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -53,6 +54,9 @@ func init() {
 	RootCmd.PersistentFlags().String("region", "", "")
 	RootCmd.PersistentFlags().String("profile", "", "")
 	RootCmd.PersistentFlags().String("stack-name", "", "")
+	RootCmd.PersistentFlags().Int("retry-max-attempts", retry.DefaultPolicy.MaxAttempts, "Maximum number of attempts at a throttled AWS API call")
+	RootCmd.PersistentFlags().Duration("retry-base-delay", retry.DefaultPolicy.BaseDelay, "Delay before retrying a throttled AWS API call, doubled for each further attempt")
+	RootCmd.PersistentFlags().Duration("retry-max-delay", retry.DefaultPolicy.MaxDelay, "Maximum delay before retrying a throttled AWS API call")
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports Persistent Flags, which, if defined here,
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/glassechidna/stackit/cmd/mask"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	ssmPrefix            = "ssm:"
+	secretsManagerPrefix = "secretsmanager:"
+)
+
+// resolveSecretParameters replaces parameter values of the form
+// ssm:<parameter-name> and secretsmanager:<secret-id>[#<json-field>] with the
+// values they refer to. SecureString and Secrets Manager values are
+// registered with the mask package so they never appear in output.
+func resolveSecretParameters(ctx context.Context, ssmApi ssmiface.SSMAPI, smApi secretsmanageriface.SecretsManagerAPI, params []*cloudformation.Parameter) error {
+	for _, param := range params {
+		value := aws.StringValue(param.ParameterValue)
+
+		var resolved string
+		var err error
+
+		if strings.HasPrefix(value, ssmPrefix) {
+			resolved, err = ssmParameterValue(ctx, ssmApi, strings.TrimPrefix(value, ssmPrefix))
+		} else if strings.HasPrefix(value, secretsManagerPrefix) {
+			resolved, err = secretsManagerValue(ctx, smApi, strings.TrimPrefix(value, secretsManagerPrefix))
+		} else {
+			continue
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "resolving parameter %s", *param.ParameterKey)
+		}
+
+		param.ParameterValue = &resolved
+	}
+
+	return nil
+}
+
+func ssmParameterValue(ctx context.Context, api ssmiface.SSMAPI, name string) (string, error) {
+	resp, err := api.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name:           &name,
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "getting ssm parameter %s", name)
+	}
+
+	value := aws.StringValue(resp.Parameter.Value)
+	if aws.StringValue(resp.Parameter.Type) == ssm.ParameterTypeSecureString {
+		mask.Add(value)
+	}
+
+	return value, nil
+}
+
+func secretsManagerValue(ctx context.Context, api secretsmanageriface.SecretsManagerAPI, ref string) (string, error) {
+	parts := strings.SplitN(ref, "#", 2)
+	id := parts[0]
+
+	resp, err := api.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: &id})
+	if err != nil {
+		return "", errors.Wrapf(err, "getting secret %s", id)
+	}
+
+	value := aws.StringValue(resp.SecretString)
+	mask.Add(value)
+
+	if len(parts) == 1 {
+		return value, nil
+	}
+
+	field := parts[1]
+	// numbers are kept as written, rather than e.g. 1000000 becoming 1e+06
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	fields := map[string]interface{}{}
+	err = decoder.Decode(&fields)
+	if err != nil {
+		return "", errors.Errorf("secret %s is not a JSON object, so field %s can't be retrieved", id, field)
+	}
+
+	fieldValue, ok := fields[field]
+	if !ok {
+		return "", errors.Errorf("secret %s has no field named %s", id, field)
+	}
+
+	value = parameterValueString(fieldValue)
+	mask.Add(value)
+	return value, nil
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/glassechidna/stackit/cmd/mask"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type fakeSsm struct {
+	ssmiface.SSMAPI
+	params map[string]*ssm.Parameter
+}
+
+func (f *fakeSsm) GetParameterWithContext(ctx aws.Context, input *ssm.GetParameterInput, opts ...request.Option) (*ssm.GetParameterOutput, error) {
+	return &ssm.GetParameterOutput{Parameter: f.params[*input.Name]}, nil
+}
+
+type fakeSecretsManager struct {
+	secretsmanageriface.SecretsManagerAPI
+	secrets map[string]string
+}
+
+func (f *fakeSecretsManager) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(f.secrets[*input.SecretId])}, nil
+}
+
+func TestResolveSecretParameters(t *testing.T) {
+	ssmApi := &fakeSsm{params: map[string]*ssm.Parameter{
+		"/app/prod/db-host":     {Type: aws.String(ssm.ParameterTypeString), Value: aws.String("db.internal")},
+		"/app/prod/db-password": {Type: aws.String(ssm.ParameterTypeSecureString), Value: aws.String("s3cr3t-db-pw")},
+	}}
+	smApi := &fakeSecretsManager{secrets: map[string]string{
+		"prod/api-key": `{"key": "s3cr3t-api-key", "id": 42}`,
+		"prod/db":      `{"port": 1000000, "weight": 1.10}`,
+	}}
+
+	params := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("DbHost"), ParameterValue: aws.String("ssm:/app/prod/db-host")},
+		{ParameterKey: aws.String("DbPassword"), ParameterValue: aws.String("ssm:/app/prod/db-password")},
+		{ParameterKey: aws.String("ApiKey"), ParameterValue: aws.String("secretsmanager:prod/api-key#key")},
+		{ParameterKey: aws.String("Plain"), ParameterValue: aws.String("value")},
+		{ParameterKey: aws.String("DbPort"), ParameterValue: aws.String("secretsmanager:prod/db#port")},
+		{ParameterKey: aws.String("DbWeight"), ParameterValue: aws.String("secretsmanager:prod/db#weight")},
+	}
+
+	err := resolveSecretParameters(context.Background(), ssmApi, smApi, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "db.internal", *params[0].ParameterValue)
+	assert.Equal(t, "s3cr3t-db-pw", *params[1].ParameterValue)
+	assert.Equal(t, "s3cr3t-api-key", *params[2].ParameterValue)
+	assert.Equal(t, "value", *params[3].ParameterValue)
+	assert.Equal(t, "1000000", *params[4].ParameterValue)
+	assert.Equal(t, "1.10", *params[5].ParameterValue)
+
+	assert.Equal(t, "db.internal **** ****", mask.String("db.internal s3cr3t-db-pw s3cr3t-api-key"))
+
+	params = []*cloudformation.Parameter{
+		{ParameterKey: aws.String("ApiKey"), ParameterValue: aws.String("secretsmanager:prod/api-key#nope")},
+	}
+	err = resolveSecretParameters(context.Background(), ssmApi, smApi, params)
+	assert.EqualError(t, err, "resolving parameter ApiKey: secret prod/api-key has no field named nope")
+}
@@ -0,0 +1,55 @@
+// Copyright © 2017 Aidan Steele <aidan.steele@glassechidna.com.au>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+var signalCmd = &cobra.Command{
+	Use:   "signal <logical-name>",
+	Short: "Send a signal to a wait condition or resource with a creation policy",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		region := viper.GetString("region")
+		profile := viper.GetString("profile")
+		stackName := viper.GetString("stack-name")
+		uniqueId, _ := cmd.PersistentFlags().GetString("unique-id")
+		status, _ := cmd.PersistentFlags().GetString("status")
+
+		sess := awsSession(profile, region)
+		sit := newStackit(sess)
+
+		ctx, end := honey.RootContext()
+		defer end()
+
+		err := sit.Signal(ctx, stackName, args[0], uniqueId, strings.ToUpper(status))
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStderr(), err)
+			defaultExiter(1)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(signalCmd)
+	signalCmd.PersistentFlags().String("unique-id", "", "Unique ID of the signal, e.g. an EC2 instance ID")
+	signalCmd.PersistentFlags().String("status", cloudformation.ResourceSignalStatusSuccess, "SUCCESS or FAILURE")
+}
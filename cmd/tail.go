@@ -15,9 +15,9 @@
 package cmd
 
 import (
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"fmt"
 	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/cmd/mask"
 	"github.com/glassechidna/stackit/pkg/stackit"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -30,10 +30,8 @@ var tailCmd = &cobra.Command{
 		region := viper.GetString("region")
 		profile := viper.GetString("profile")
 		stackName := viper.GetString("stack-name")
-		printer := stackit.NewTailPrinter(cmd.OutOrStderr())
-
 		sess := awsSession(profile, region)
-		sit := stackit.NewStackit(cloudformation.New(sess), sts.New(sess))
+		sit := newStackit(sess)
 
 		ctx, end := honey.RootContext()
 		defer end()
@@ -43,15 +41,36 @@ var tailCmd = &cobra.Command{
 			return
 		}
 
-		_, err := sit.PollStackEvents(ctx, *stack.StackId, "", func(event stackit.TailStackEvent) {
-			printer.PrintTailEvent(event)
+		ep, err := startEventPrinter(ctx, cmd, tailResources(ctx, sit, stackName, nil))
+		if err != nil {
+			panic(err)
+		}
+		defer ep.Stop()
+
+		printed := []stackit.TailStackEvent{}
+		_, err = sit.PollStackEvents(ctx, *stack.StackId, "", func(event stackit.TailStackEvent) {
+			ep.Events <- event
+			printed = append(printed, event)
 		})
 		if err != nil {
 			panic(err)
 		}
+
+		success, _ := sit.IsSuccessfulState(ctx, *stack.StackId)
+		if !success {
+			ep.Stop()
+			if final, _ := sit.Describe(ctx, *stack.StackId); final != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "\n%s", mask.String(stackit.NewFailureSummary(final, printed).String()))
+			}
+		}
+
+		if jsonOutput(cmd) {
+			ep.Summary(ctx, sit, *stack.StackId, success)
+		}
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(tailCmd)
+	addEventFlags(tailCmd)
 }
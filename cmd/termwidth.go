@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	"golang.org/x/sys/unix"
+	"io"
+	"os"
+)
+
+// terminalSize returns the width and height of the terminal w is writing to,
+// or zeroes if it isn't writing to a terminal.
+func terminalSize(w io.Writer) (int, int) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, 0
+	}
+
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0
+	}
+
+	return int(ws.Col), int(ws.Row)
+}
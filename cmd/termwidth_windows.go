@@ -0,0 +1,9 @@
+package cmd
+
+import "io"
+
+// terminalSize always returns zeroes on Windows, which disables wrapping and
+// the dashboard.
+func terminalSize(w io.Writer) (int, int) {
+	return 0, 0
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"io"
+	"time"
+)
+
+var errTimedOut = errors.New("timed out")
+
+const (
+	phaseChangeSet = "change set creation"
+	phaseExecution = "execution"
+	phaseDeletion  = "deletion"
+)
+
+// phaseTimeoutFlags are the flags that limit how long each phase may take.
+var phaseTimeoutFlags = map[string]string{
+	phaseChangeSet: "change-set-timeout",
+	phaseExecution: "execution-timeout",
+	phaseDeletion:  "deletion-timeout",
+}
+
+func addTimeoutFlags(cmd *cobra.Command, phases ...string) {
+	cmd.PersistentFlags().Duration("timeout", 0, "Give up if the whole operation takes longer than this, e.g. 30m (default no limit)")
+	for _, phase := range phases {
+		cmd.PersistentFlags().Duration(phaseTimeoutFlags[phase], 0, fmt.Sprintf("Give up if %s takes longer than this (default no limit)", phase))
+	}
+}
+
+// timeouts limits how long a command and each of its phases may take.
+type timeouts struct {
+	deadline time.Time
+	phases   map[string]time.Duration
+}
+
+// newTimeouts starts the clock on the command's --timeout and reads the
+// timeouts of its phases.
+func newTimeouts(cmd *cobra.Command) timeouts {
+	t := timeouts{phases: map[string]time.Duration{}}
+
+	if timeout, _ := cmd.PersistentFlags().GetDuration("timeout"); timeout > 0 {
+		t.deadline = time.Now().Add(timeout)
+	}
+
+	for phase, flag := range phaseTimeoutFlags {
+		t.phases[phase], _ = cmd.PersistentFlags().GetDuration(flag)
+	}
+
+	return t
+}
+
+// phase returns a context for the phase that is cancelled once either the
+// phase or the whole command has run out of time.
+func (t timeouts) phase(ctx context.Context, phase string) (context.Context, context.CancelFunc) {
+	deadline := t.deadline
+	if timeout := t.phases[phase]; timeout > 0 {
+		if phaseDeadline := time.Now().Add(timeout); deadline.IsZero() || phaseDeadline.Before(deadline) {
+			deadline = phaseDeadline
+		}
+	}
+
+	if deadline.IsZero() {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+func timedOut(ctx context.Context) bool {
+	return ctx.Err() == context.DeadlineExceeded
+}
+
+// reportTimeout explains which phase of an operation on the stack timed out
+// and the status the stack was left in.
+func reportTimeout(ctx context.Context, sit *stackit.Stackit, stackName, phase string, w io.Writer) {
+	status := "does not exist"
+	if stack, _ := sit.Describe(ctx, stackName); stack != nil {
+		status = *stack.StackStatus
+	}
+
+	fmt.Fprintf(w, "Timed out during %s. Stack %s is %s.\n", phase, stackName, status)
+}
+
+// reportAbandonedChangeSet says what happened to the change set that was
+// being created when the change set creation phase timed out, if any.
+func reportAbandonedChangeSet(err error, w io.Writer) {
+	abandoned, ok := err.(*stackit.AbandonedChangeSetError)
+	if !ok {
+		return
+	}
+
+	if abandoned.DeleteErr != nil {
+		fmt.Fprintf(w, "Couldn't delete unfinished change set %s: %s\n", abandoned.ChangeSetId, abandoned.DeleteErr)
+		return
+	}
+
+	fmt.Fprintf(w, "Deleted unfinished change set %s\n", abandoned.ChangeSetId)
+}
+
+// cancelOnTimeout cancels the update of a stack that ran out of time, if
+// asked to with --cancel-on-timeout, and waits for it to roll back.
+func cancelOnTimeout(ctx context.Context, cmd *cobra.Command, sit *stackit.Stackit, stackId string, events chan<- stackit.TailStackEvent) {
+	if cancel, _ := cmd.PersistentFlags().GetBool("cancel-on-timeout"); !cancel {
+		return
+	}
+
+	fmt.Fprintf(cmd.OutOrStderr(), "Cancelling update of stack %s\n", stackId)
+	err := sit.Cancel(ctx, stackId, events)
+	if err != nil {
+		fmt.Fprintf(cmd.OutOrStderr(), "Couldn't cancel update: %s\n", err)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTimeoutsPhase(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		addTimeoutFlags(cmd, phaseChangeSet, phaseExecution)
+		assert.NoError(t, cmd.PersistentFlags().Parse(args))
+		return cmd
+	}
+
+	t.Run("no limits", func(t *testing.T) {
+		ctx, cancel := newTimeouts(newCmd()).phase(context.Background(), phaseExecution)
+		defer cancel()
+		_, ok := ctx.Deadline()
+		assert.False(t, ok)
+	})
+
+	t.Run("phase limit sooner than overall", func(t *testing.T) {
+		ctx, cancel := newTimeouts(newCmd("--timeout", "1h", "--execution-timeout", "10m")).phase(context.Background(), phaseExecution)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), deadline, time.Second)
+	})
+
+	t.Run("overall limit sooner than phase", func(t *testing.T) {
+		ctx, cancel := newTimeouts(newCmd("--timeout", "5m", "--execution-timeout", "10m")).phase(context.Background(), phaseExecution)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), deadline, time.Second)
+	})
+
+	t.Run("other phases are only limited overall", func(t *testing.T) {
+		ctx, cancel := newTimeouts(newCmd("--timeout", "1h", "--execution-timeout", "10m")).phase(context.Background(), phaseChangeSet)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Second)
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		ctx, cancel := newTimeouts(newCmd("--change-set-timeout", "1ns")).phase(context.Background(), phaseChangeSet)
+		defer cancel()
+		<-ctx.Done()
+		assert.True(t, timedOut(ctx))
+	})
+}
+
+func TestReportAbandonedChangeSet(t *testing.T) {
+	buf := &bytes.Buffer{}
+	reportAbandonedChangeSet(&stackit.AbandonedChangeSetError{ChangeSetId: "cs-1", Err: context.DeadlineExceeded}, buf)
+	reportAbandonedChangeSet(&stackit.AbandonedChangeSetError{ChangeSetId: "cs-2", Err: context.DeadlineExceeded, DeleteErr: errors.New("throttled")}, buf)
+	reportAbandonedChangeSet(errors.New("other"), buf)
+	assert.Equal(t, "Deleted unfinished change set cs-1\nCouldn't delete unfinished change set cs-2: throttled\n", buf.String())
+}
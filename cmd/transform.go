@@ -16,8 +16,6 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/glassechidna/stackit/cmd/honey"
 	"github.com/glassechidna/stackit/pkg/stackit"
 	"github.com/spf13/cobra"
@@ -36,10 +34,8 @@ var transformCmd = &cobra.Command{
 		params := keyvalSliceToMap(args)
 
 		sess := awsSession(profile, region)
-		sit := stackit.NewStackit(
-			cloudformation.New(sess),
-			sts.New(sess),
-		)
+		sit := newStackit(sess)
+		sit.SetTemplateUploader(newPackager(sess))
 
 		original, err := ioutil.ReadFile(templatePath)
 		if err != nil {
@@ -49,7 +45,15 @@ var transformCmd = &cobra.Command{
 		ctx, end := honey.RootContext()
 		defer end()
 
-		processed, err := sit.Transform(ctx, string(original), params)
+		capabilities, infer := capabilitiesFlag(cmd)
+		if infer {
+			capabilities, err = stackit.InferCapabilities(string(original))
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		processed, err := sit.Transform(ctx, string(original), params, capabilities)
 		if err != nil {
 			panic(err)
 		}
@@ -61,4 +65,5 @@ var transformCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(transformCmd)
 	transformCmd.PersistentFlags().String("template", "", "")
+	addCapabilitiesFlag(transformCmd)
 }
@@ -15,24 +15,31 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/cloudformation"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/glassechidna/stackit/cmd/honey"
+	"github.com/glassechidna/stackit/cmd/mask"
 	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/mattn/go-isatty"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"io"
+	"io/ioutil"
 	"os"
 	"strings"
 )
 
 func printUntilDone(ctx context.Context, events <-chan stackit.TailStackEvent, w io.Writer) {
-	printer := stackit.NewTailPrinter(w)
+	printEventsUntilDone(ctx, events, stackit.NewTailPrinter(mask.Writer(w)))
+}
 
+func printEventsUntilDone(ctx context.Context, events <-chan stackit.TailStackEvent, printer stackit.TailPrinter) {
 	for {
 		select {
 		case tailEvent := <-events:
@@ -59,16 +66,39 @@ func keyvalSliceToMap(slice []string) map[string]string {
 	return theMap
 }
 
-func parseCLIInput(cmd *cobra.Command, args []string) stackit.StackitUpInput {
+func parseCLIInput(cmd *cobra.Command, args []string) (stackit.StackitUpInput, error) {
 	stackName, _ := RootCmd.PersistentFlags().GetString("stack-name")
 	serviceRole, _ := cmd.PersistentFlags().GetString("service-role")
 	template, _ := cmd.PersistentFlags().GetString("template")
 	tags, _ := cmd.PersistentFlags().GetStringSlice("tag")
 	notificationArns, _ := cmd.PersistentFlags().GetStringSlice("notification-arn")
+	continueUpdateRollback, _ := cmd.PersistentFlags().GetBool("continue-update-rollback")
+	skipFailedRollbackResources, _ := cmd.PersistentFlags().GetBool("skip-failed-rollback-resources")
+	stackPolicy, _ := cmd.PersistentFlags().GetString("stack-policy")
+	stackPolicyDuringUpdate, _ := cmd.PersistentFlags().GetString("stack-policy-during-update")
+	parametersFiles, _ := cmd.PersistentFlags().GetStringArray("parameters-file")
+	capabilities, inferCapabilities := capabilitiesFlag(cmd)
+	rollbackAlarms, _ := cmd.PersistentFlags().GetStringArray("rollback-alarm")
+	rollbackMonitoringMinutes, _ := cmd.PersistentFlags().GetInt64("rollback-monitoring-minutes")
+	warnOnDrift, _ := cmd.PersistentFlags().GetBool("warn-on-drift")
+	failOnDrift, _ := cmd.PersistentFlags().GetBool("fail-on-drift")
 
 	input := stackit.StackitUpInput{
-		StackName:       stackName,
-		PopulateMissing: true,
+		StackName:                   stackName,
+		PopulateMissing:             true,
+		ContinueUpdateRollback:      continueUpdateRollback,
+		SkipFailedRollbackResources: skipFailedRollbackResources,
+		Capabilities:                capabilities,
+		InferCapabilities:           inferCapabilities,
+		RollbackAlarmARNs:           rollbackAlarms,
+		RollbackMonitoringMinutes:   rollbackMonitoringMinutes,
+		WarnOnDrift:                 warnOnDrift,
+		FailOnDrift:                 failOnDrift,
+	}
+
+	if cmd.PersistentFlags().Changed("termination-protection") {
+		terminationProtection, _ := cmd.PersistentFlags().GetBool("termination-protection")
+		input.TerminationProtection = &terminationProtection
 	}
 
 	if len(serviceRole) > 0 {
@@ -79,88 +109,346 @@ func parseCLIInput(cmd *cobra.Command, args []string) stackit.StackitUpInput {
 		var err error
 		input.Template, err = pathToTemplate(template)
 		if err != nil {
-			panic(err)
-			// TODO
+			return input, err
 		}
 	} else {
 		input.PreviousTemplate = true
 	}
 
-	params := []*cloudformation.Parameter{}
-	for name, value := range keyvalSliceToMap(args) {
-		params = append(params, &cloudformation.Parameter{
-			ParameterKey:   aws.String(name),
-			ParameterValue: aws.String(value),
-		})
+	files := []*parametersFile{}
+	for _, path := range parametersFiles {
+		pf, err := readParametersFile(path)
+		if err != nil {
+			return input, err
+		}
+		files = append(files, pf)
 	}
 
-	input.Parameters = params
+	input.Parameters = mergeParameters(files, keyvalSliceToMap(args))
 	input.NotificationARNs = notificationArns
 
-	if len(tags) > 0 {
-		input.Tags = keyvalSliceToMap(tags)
+	fileTags := map[string]string{}
+	for _, pf := range files {
+		for key, value := range pf.Tags {
+			fileTags[key] = value
+		}
+		if len(pf.StackPolicy) > 0 {
+			input.StackPolicyBody = pf.StackPolicy
+		}
+	}
+
+	if len(tags) > 0 || len(fileTags) > 0 {
+		input.Tags = fileTags
+		for key, value := range keyvalSliceToMap(tags) {
+			input.Tags[key] = value
+		}
+	}
+
+	if len(stackPolicy) > 0 {
+		var err error
+		input.StackPolicyBody, err = readStackPolicy(stackPolicy)
+		if err != nil {
+			return input, err
+		}
+	} else if len(input.StackPolicyBody) > 0 {
+		err := stackit.ValidateStackPolicy(input.StackPolicyBody)
+		if err != nil {
+			return input, errors.Wrap(err, "validating stack policy in parameters file")
+		}
+	}
+
+	var err error
+	input.StackPolicyDuringUpdateBody, err = readStackPolicy(stackPolicyDuringUpdate)
+	if err != nil {
+		return input, err
 	}
 
-	return input
+	return input, nil
+}
+
+func readStackPolicy(path string) (string, error) {
+	if len(path) == 0 {
+		return "", nil
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "reading stack policy")
+	}
+
+	err = stackit.ValidateStackPolicy(string(body))
+	if err != nil {
+		return "", errors.Wrapf(err, "validating stack policy in %s", path)
+	}
+
+	return string(body), nil
 }
 
 var errUnsuccessfulStack = errors.New("stack update unsuccessful")
+var errChangeSetDeclined = errors.New("change set not executed")
+
+var isInteractive = func() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+func confirmChangeSet(cmd *cobra.Command, prepared *stackit.PrepareOutput, w io.Writer) error {
+	yes, _ := cmd.PersistentFlags().GetBool("yes")
+	allowReplacements, _ := cmd.PersistentFlags().GetBool("allow-replacements")
+
+	fmt.Fprintln(w, userFriendlyChangesOutput(prepared))
+
+	if yes || !isInteractive() {
+		replacements := prepared.Replacements()
+		if len(replacements) == 0 || allowReplacements {
+			return nil
+		}
+
+		names := []string{}
+		for _, rc := range replacements {
+			names = append(names, *rc.LogicalResourceId)
+		}
+
+		fmt.Fprintf(w, "Refusing to execute change set that replaces %s. Pass --allow-replacements to proceed.\n", strings.Join(names, ", "))
+		return errChangeSetDeclined
+	}
+
+	fmt.Fprint(w, "Execute change set? [y/N] ")
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		fmt.Fprintf(w, "Change set %s was not executed\n", *prepared.Output.Id)
+		return errChangeSetDeclined
+	}
+}
+
+func prepare(ctx context.Context, cmd *cobra.Command, input stackit.StackitUpInput, sess *session.Session, sit *stackit.Stackit, events chan<- stackit.TailStackEvent, w io.Writer) (*stackit.PrepareOutput, error) {
+	err := resolveSecretParameters(ctx, ssm.New(sess), secretsmanager.New(sess), input.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sit.ResolveParameterReferences(ctx, input.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	input.RollbackAlarmARNs, err = resolveAlarmArns(ctx, cloudwatch.New(sess), input.RollbackAlarmARNs)
+	if err != nil {
+		return nil, err
+	}
+
+	if templateFile, ok := input.Template.(*templateReader); ok && templateFile != nil {
+		template, err := packageTemplate(ctx, sess, input.StackName, templateFile, w)
+		if err != nil {
+			return nil, errors.Wrap(err, "packaging template")
+		}
+		templateFile.body = *template
+	}
+
+	prepared, err := sit.Prepare(ctx, input, events)
+	if driftErr, ok := err.(*stackit.DriftedChangesError); ok {
+		fmt.Fprintf(w, "Refusing to update: %s\n%s", driftErr, driftTable(driftErr.Resources))
+		return nil, errDriftDetected
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if prepared != nil && len(prepared.DriftedResources) > 0 {
+		fmt.Fprintf(w, "Warning: these resources have drifted and would be changed by this update:\n%s", driftTable(prepared.DriftedResources))
+	}
+
+	return prepared, nil
+}
 
 func up(cmd *cobra.Command, args []string) error {
+	input, err := parseCLIInput(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	return upWithInput(cmd, input)
+}
+
+func upWithInput(cmd *cobra.Command, input stackit.StackitUpInput) error {
 	region := viper.GetString("region")
 	profile := viper.GetString("profile")
-	input := parseCLIInput(cmd, args)
 
 	sess := awsSession(profile, region)
-	sit := stackit.NewStackit(cloudformation.New(sess), sts.New(sess))
+	sit := newStackit(sess)
+	sit.SetTemplateUploader(newPackager(sess))
 
 	ctx, end := honey.RootContext()
 	defer end()
 
-	printerCtx, printerCancel := context.WithCancel(ctx)
-	defer printerCancel()
-
-	if templateFile, ok := input.Template.(*templateReader); ok && templateFile != nil {
-		template, err := packageTemplate(ctx, sess, input.StackName, templateFile, cmd.OutOrStderr())
-		if err != nil {
-			return errors.Wrap(err, "packaging template")
-		}
-		templateFile.body = *template
+	ep, err := startEventPrinter(ctx, cmd, tailResources(ctx, sit, input.StackName, input.Template))
+	if err != nil {
+		return err
 	}
+	defer ep.Stop()
+	events := ep.Events
 
-	events := make(chan stackit.TailStackEvent)
-	go printUntilDone(printerCtx, events, cmd.OutOrStderr())
+	timeouts := newTimeouts(cmd)
 
-	prepared, err := sit.Prepare(ctx, input, events)
+	prepareCtx, cancel := timeouts.phase(ctx, phaseChangeSet)
+	prepared, err := prepare(prepareCtx, cmd, input, sess, sit, events, cmd.OutOrStderr())
+	cancel()
+	if timedOut(prepareCtx) {
+		ep.Stop()
+		reportTimeout(ctx, sit, input.StackName, phaseChangeSet, cmd.OutOrStderr())
+		reportAbandonedChangeSet(err, cmd.OutOrStderr())
+		return errTimedOut
+	}
 	if err != nil {
 		return err
 	}
 
 	if prepared == nil {
+		err = setStackPolicyWithoutChanges(ctx, sit, input)
+		if err != nil {
+			return err
+		}
+
+		err = setTerminationProtection(ctx, sit, input)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput(cmd) {
+			ep.Summary(ctx, sit, input.StackName, true)
+		}
 		return nil
 	}
 
-	err = sit.Execute(ctx, *prepared.Output.StackId, *prepared.Output.Id, events)
+	err = confirmChangeSet(cmd, prepared, cmd.OutOrStderr())
 	if err != nil {
 		return err
 	}
 
 	stackId := *prepared.Output.StackId
+
+	executeCtx, cancel := timeouts.phase(ctx, phaseExecution)
+	err = sit.ExecuteWithStackPolicy(executeCtx, stackId, *prepared.Output.Id, input.StackPolicyBody, input.StackPolicyDuringUpdateBody, events)
+	cancel()
+	if timedOut(executeCtx) {
+		reportTimeout(ctx, sit, input.StackName, phaseExecution, cmd.OutOrStderr())
+		cancelOnTimeout(ctx, cmd, sit, stackId, events)
+		if jsonOutput(cmd) {
+			ep.Summary(ctx, sit, stackId, false)
+		}
+		return errTimedOut
+	}
+	if err != nil {
+		return err
+	}
+
 	if success, _ := sit.IsSuccessfulState(ctx, stackId); !success {
+		ep.Stop()
+		reportFailure(ctx, sit, stackId, cmd.OutOrStderr())
+		reportRollbackAlarms(ctx, sit, stackId, cmd.OutOrStderr())
+		if jsonOutput(cmd) {
+			ep.Summary(ctx, sit, stackId, false)
+		}
 		return errUnsuccessfulStack
 	}
 
+	err = setTerminationProtection(ctx, sit, input)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput(cmd) {
+		ep.Summary(ctx, sit, stackId, true)
+		return nil
+	}
+
 	sit.PrintOutputs(ctx, stackId, cmd.OutOrStdout())
 	return nil
 }
 
+func setTerminationProtection(ctx context.Context, sit *stackit.Stackit, input stackit.StackitUpInput) error {
+	if input.TerminationProtection == nil {
+		return nil
+	}
+
+	return sit.SetTerminationProtection(ctx, input.StackName, *input.TerminationProtection)
+}
+
+// reportRollbackAlarms explains an unsuccessful update that was rolled back
+// by a rollback alarm rather than by a resource failure.
+func reportRollbackAlarms(ctx context.Context, sit *stackit.Stackit, stackId string, w io.Writer) {
+	alarms, err := sit.TriggeredRollbackAlarms(ctx, stackId)
+	if err != nil || len(alarms) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "Stack was rolled back because rollback alarms fired: %s\n", strings.Join(alarms, ", "))
+}
+
+// setStackPolicyWithoutChanges applies the stack policy even when the stack
+// is otherwise up to date, as policies aren't part of change sets.
+func setStackPolicyWithoutChanges(ctx context.Context, sit *stackit.Stackit, input stackit.StackitUpInput) error {
+	if len(input.StackPolicyBody) == 0 {
+		return nil
+	}
+
+	stack, err := sit.Describe(ctx, input.StackName)
+	if err != nil || stack == nil {
+		return err
+	}
+
+	return sit.SetStackPolicy(ctx, *stack.StackId, input.StackPolicyBody)
+}
+
+func addPrepareFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("service-role", "", "")
+	cmd.PersistentFlags().String("template", "", "")
+	cmd.PersistentFlags().StringSliceP("tag", "t", []string{}, "")
+	cmd.PersistentFlags().StringSlice("notification-arn", []string{}, "")
+	cmd.PersistentFlags().Bool("continue-update-rollback", false, "Recover stack from UPDATE_ROLLBACK_FAILED state before updating")
+	cmd.PersistentFlags().Bool("skip-failed-rollback-resources", false, "Skip resources that failed to roll back when recovering stack")
+	cmd.PersistentFlags().String("stack-policy", "", "Path to stack policy to set after successful create or update")
+	cmd.PersistentFlags().String("stack-policy-during-update", "", "Path to stack policy that temporarily overrides the stack policy during update")
+	cmd.PersistentFlags().StringArray("parameters-file", []string{}, "Path to parameters file (repeatable, later files take precedence)")
+	cmd.PersistentFlags().StringArray("rollback-alarm", []string{}, "Name or ARN of CloudWatch alarm that rolls back the stack (repeatable)")
+	cmd.PersistentFlags().Bool("warn-on-drift", false, "Detect drift before updating and warn about drifted resources the update would change")
+	cmd.PersistentFlags().Bool("fail-on-drift", false, "Detect drift before updating and refuse to change drifted resources")
+	cmd.PersistentFlags().Int64("rollback-monitoring-minutes", 0, "Minutes to keep monitoring rollback alarms after resources are deployed")
+	addCapabilitiesFlag(cmd)
+}
+
+const inferCapabilitiesValue = "infer"
+
+func addCapabilitiesFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSlice("capabilities", nil, "Capabilities to grant, or \"infer\" to grant those required by the template (default all)")
+}
+
+// capabilitiesFlag returns nil capabilities if the flag wasn't passed, so
+// that the default capabilities are granted.
+func capabilitiesFlag(cmd *cobra.Command) ([]string, bool) {
+	if !cmd.PersistentFlags().Changed("capabilities") {
+		return nil, false
+	}
+
+	capabilities, _ := cmd.PersistentFlags().GetStringSlice("capabilities")
+	if len(capabilities) == 1 && capabilities[0] == inferCapabilitiesValue {
+		return nil, true
+	}
+
+	return capabilities, false
+}
+
 func init() {
 	upCmd := &cobra.Command{
 		Use:   "up",
 		Short: "Bring stack up to date",
 		Run: func(cmd *cobra.Command, args []string) {
 			err := up(cmd, args)
-			if err == errUnsuccessfulStack {
+			if err == errUnsuccessfulStack || err == errChangeSetDeclined || err == errDriftDetected || err == errTimedOut {
 				defaultExiter(1)
 			} else if err != nil {
 				panic(err)
@@ -169,10 +457,13 @@ func init() {
 	}
 	RootCmd.AddCommand(upCmd)
 
-	upCmd.PersistentFlags().String("service-role", "", "")
-	upCmd.PersistentFlags().String("template", "", "")
-	upCmd.PersistentFlags().StringSliceP("tag", "t", []string{}, "")
-	upCmd.PersistentFlags().StringSlice("notification-arn", []string{}, "")
+	addPrepareFlags(upCmd)
+	addEventFlags(upCmd)
+	upCmd.PersistentFlags().BoolP("yes", "y", false, "Execute change set without prompting for confirmation")
+	upCmd.PersistentFlags().Bool("allow-replacements", false, "Allow non-interactive execution of change sets that replace resources")
+	upCmd.PersistentFlags().Bool("termination-protection", false, "Enable (or with =false, disable) termination protection after a successful update")
+	addTimeoutFlags(upCmd, phaseChangeSet, phaseExecution)
+	upCmd.PersistentFlags().Bool("cancel-on-timeout", false, "Cancel the stack update if execution times out")
 }
 
 var defaultExiter = os.Exit
@@ -5,6 +5,8 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/stackit/pkg/stackit"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"os"
@@ -67,8 +69,10 @@ func TestUp_DoesntHangWhenCreationCancelled(t *testing.T) {
 		_ = RootCmd.Execute()
 	})
 
+	// the resource column is as wide as the longest logical ID, which here
+	// is the stack's own name
 	actual := outputcopy.String()
-	assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d] test-cancelled-stack - CREATE_IN_PROGRESS - User Initiated
+	assert.Regexp(t, regexp.MustCompile(`\[\d\d:\d\d:\d\d] test-cancelled-stack - CREATE_IN_PROGRESS - User Initiated
 \[\d\d:\d\d:\d\d]             LogGroup - CREATE_IN_PROGRESS 
 \[\d\d:\d\d:\d\d]             LogGroup - CREATE_IN_PROGRESS - Resource creation Initiated
 \[\d\d:\d\d:\d\d]             LogGroup - CREATE_COMPLETE 
@@ -103,17 +107,17 @@ func TestUp(t *testing.T) {
 
 		_ = RootCmd.Execute()
 
-		assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d]           test-stack - CREATE_IN_PROGRESS - User Initiated
-\[\d\d:\d\d:\d\d]             LogGroup - CREATE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]             LogGroup - CREATE_IN_PROGRESS - Resource creation Initiated
-\[\d\d:\d\d:\d\d]             LogGroup - CREATE_COMPLETE 
-\[\d\d:\d\d:\d\d]              TaskDef - CREATE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]              TaskDef - CREATE_IN_PROGRESS - Resource creation Initiated
-\[\d\d:\d\d:\d\d]              TaskDef - CREATE_COMPLETE 
-\[\d\d:\d\d:\d\d]          TargetGroup - CREATE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]          TargetGroup - CREATE_IN_PROGRESS - Resource creation Initiated
-\[\d\d:\d\d:\d\d]          TargetGroup - CREATE_COMPLETE 
-\[\d\d:\d\d:\d\d]           test-stack - CREATE_COMPLETE 
+		assert.Regexp(t, regexp.MustCompile(`\[\d\d:\d\d:\d\d]  test-stack - CREATE_IN_PROGRESS - User Initiated
+\[\d\d:\d\d:\d\d]    LogGroup - CREATE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d]    LogGroup - CREATE_IN_PROGRESS - Resource creation Initiated
+\[\d\d:\d\d:\d\d]    LogGroup - CREATE_COMPLETE 
+\[\d\d:\d\d:\d\d]     TaskDef - CREATE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d]     TaskDef - CREATE_IN_PROGRESS - Resource creation Initiated
+\[\d\d:\d\d:\d\d]     TaskDef - CREATE_COMPLETE 
+\[\d\d:\d\d:\d\d] TargetGroup - CREATE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d] TargetGroup - CREATE_IN_PROGRESS - Resource creation Initiated
+\[\d\d:\d\d:\d\d] TargetGroup - CREATE_COMPLETE 
+\[\d\d:\d\d:\d\d]  test-stack - CREATE_COMPLETE 
 \{
   "LogGroup": "test-stack-LogGroup",
   "TaskDef": "arn:aws:ecs:ap-southeast-2:607481581596:task-definition/ecs-run-task-test:\d+"
@@ -135,11 +139,11 @@ func TestUp(t *testing.T) {
 
 		_ = RootCmd.Execute()
 
-		assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d]           test-stack - UPDATE_IN_PROGRESS - User Initiated
-\[\d\d:\d\d:\d\d]          TargetGroup - UPDATE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]          TargetGroup - UPDATE_COMPLETE 
-\[\d\d:\d\d:\d\d]           test-stack - UPDATE_COMPLETE_CLEANUP_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]           test-stack - UPDATE_COMPLETE 
+		assert.Regexp(t, regexp.MustCompile(`\[\d\d:\d\d:\d\d]  test-stack - UPDATE_IN_PROGRESS - User Initiated
+\[\d\d:\d\d:\d\d] TargetGroup - UPDATE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d] TargetGroup - UPDATE_COMPLETE 
+\[\d\d:\d\d:\d\d]  test-stack - UPDATE_COMPLETE_CLEANUP_IN_PROGRESS 
+\[\d\d:\d\d:\d\d]  test-stack - UPDATE_COMPLETE 
 \{
   "LogGroup": "test-stack-LogGroup",
   "TaskDef": "arn:aws:ecs:ap-southeast-2:607481581596:task-definition/ecs-run-task-test:\d+"
@@ -159,13 +163,13 @@ func TestUp(t *testing.T) {
 
 		_ = RootCmd.Execute()
 
-		assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d]           test-stack - DELETE_IN_PROGRESS - User Initiated
-\[\d\d:\d\d:\d\d]          TargetGroup - DELETE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]          TargetGroup - DELETE_COMPLETE 
-\[\d\d:\d\d:\d\d]              TaskDef - DELETE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]              TaskDef - DELETE_COMPLETE 
-\[\d\d:\d\d:\d\d]             LogGroup - DELETE_IN_PROGRESS 
-\[\d\d:\d\d:\d\d]             LogGroup - DELETE_COMPLETE`), buf.String())
+		assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d]  test-stack - DELETE_IN_PROGRESS - User Initiated
+\[\d\d:\d\d:\d\d] TargetGroup - DELETE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d] TargetGroup - DELETE_COMPLETE 
+\[\d\d:\d\d:\d\d]     TaskDef - DELETE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d]     TaskDef - DELETE_COMPLETE 
+\[\d\d:\d\d:\d\d]    LogGroup - DELETE_IN_PROGRESS 
+\[\d\d:\d\d:\d\d]    LogGroup - DELETE_COMPLETE`), buf.String())
 	})
 
 }
@@ -188,3 +192,106 @@ func TestKeyvalSliceToMap(t *testing.T) {
 	m = keyvalSliceToMap([]string{"a=b", "cd"})
 	assert.Len(t, m, 1)
 }
+
+func TestConfirmChangeSet(t *testing.T) {
+	prepared := &stackit.PrepareOutput{
+		Output: &cloudformation.CreateChangeSetOutput{
+			Id:      aws.String("arn:aws:cloudformation:ap-southeast-2:123456789012:changeSet/cs/abc"),
+			StackId: aws.String("arn:aws:cloudformation:ap-southeast-2:123456789012:stack/stack/def"),
+		},
+		Changes: []*cloudformation.Change{
+			{
+				ResourceChange: &cloudformation.ResourceChange{
+					Action:            aws.String(cloudformation.ChangeActionModify),
+					LogicalResourceId: aws.String("Bucket"),
+					ResourceType:      aws.String("AWS::S3::Bucket"),
+					Replacement:       aws.String(cloudformation.ReplacementTrue),
+					Scope:             aws.StringSlice([]string{cloudformation.ResourceAttributeProperties}),
+				},
+			},
+		},
+	}
+
+	newCmd := func(args ...string) (*cobra.Command, *bytes.Buffer) {
+		cmd := &cobra.Command{}
+		cmd.PersistentFlags().Bool("yes", false, "")
+		cmd.PersistentFlags().Bool("allow-replacements", false, "")
+		_ = cmd.PersistentFlags().Parse(args)
+
+		buf := &bytes.Buffer{}
+		cmd.SetOutput(buf)
+		return cmd, buf
+	}
+
+	defer func(orig func() bool) { isInteractive = orig }(isInteractive)
+
+	t.Run("non-interactive refuses replacements", func(t *testing.T) {
+		isInteractive = func() bool { return false }
+		cmd, buf := newCmd("--yes")
+		assert.Equal(t, errChangeSetDeclined, confirmChangeSet(cmd, prepared, cmd.OutOrStderr()))
+		assert.Contains(t, buf.String(), "| Modify | Bucket   | AWS::S3::Bucket | True        | Properties |")
+		assert.Contains(t, buf.String(), "Refusing to execute change set that replaces Bucket")
+	})
+
+	t.Run("non-interactive allows replacements when asked", func(t *testing.T) {
+		isInteractive = func() bool { return false }
+		cmd, _ := newCmd("--yes", "--allow-replacements")
+		assert.NoError(t, confirmChangeSet(cmd, prepared, cmd.OutOrStderr()))
+	})
+
+	t.Run("interactive prompt accepted", func(t *testing.T) {
+		isInteractive = func() bool { return true }
+		cmd, buf := newCmd()
+		cmd.SetIn(strings.NewReader("y\n"))
+		assert.NoError(t, confirmChangeSet(cmd, prepared, cmd.OutOrStderr()))
+		assert.Contains(t, buf.String(), "Execute change set? [y/N]")
+	})
+
+	t.Run("interactive prompt declined", func(t *testing.T) {
+		isInteractive = func() bool { return true }
+		cmd, _ := newCmd()
+		cmd.SetIn(strings.NewReader("\n"))
+		assert.Equal(t, errChangeSetDeclined, confirmChangeSet(cmd, prepared, cmd.OutOrStderr()))
+	})
+}
+
+func TestTailPrinterFitsTemplateResources(t *testing.T) {
+	template, err := pathToTemplate("../sample/sample.yml")
+	assert.NoError(t, err)
+
+	cmd := &cobra.Command{}
+	addEventFlags(cmd)
+	buf := &bytes.Buffer{}
+	cmd.SetOutput(buf)
+
+	// TargetGroup is the longest logical ID in the sample template
+	printer, err := newTailPrinter(cmd, templateResources("test-stack", template))
+	assert.NoError(t, err)
+
+	for _, name := range []string{"test-stack", "LogGroup", "TaskDef", "TargetGroup"} {
+		printer.PrintTailEvent(stackit.TailStackEvent{StackEvent: cloudformation.StackEvent{
+			Timestamp:         aws.Time(time.Now()),
+			LogicalResourceId: aws.String(name),
+			ResourceStatus:    aws.String(cloudformation.ResourceStatusCreateComplete),
+		}})
+	}
+
+	assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d]  test-stack - CREATE_COMPLETE 
+\[\d\d:\d\d:\d\d]    LogGroup - CREATE_COMPLETE 
+\[\d\d:\d\d:\d\d]     TaskDef - CREATE_COMPLETE 
+\[\d\d:\d\d:\d\d] TargetGroup - CREATE_COMPLETE 
+$`), buf.String())
+
+	// a stack name longer than every logical ID sets the width instead
+	printer, err = newTailPrinter(cmd, templateResources("test-cancelled-stack-with-long-name", template))
+	assert.NoError(t, err)
+
+	buf.Reset()
+	printer.PrintTailEvent(stackit.TailStackEvent{StackEvent: cloudformation.StackEvent{
+		Timestamp:         aws.Time(time.Now()),
+		LogicalResourceId: aws.String("LogGroup"),
+		ResourceStatus:    aws.String(cloudformation.ResourceStatusCreateComplete),
+	}})
+	assert.Regexp(t, regexp.MustCompile(`^\[\d\d:\d\d:\d\d] {28}LogGroup - CREATE_COMPLETE 
+$`), buf.String())
+}
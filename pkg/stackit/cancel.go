@@ -0,0 +1,38 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+)
+
+// Cancel cancels an in-progress stack update and streams events until the
+// resulting rollback has finished.
+func (s *Stackit) Cancel(ctx context.Context, stackName string, events chan<- TailStackEvent) error {
+	stack, err := s.Describe(ctx, stackName)
+	if err != nil {
+		return err
+	}
+
+	if stack == nil {
+		return errors.Errorf("stack %s does not exist", stackName)
+	}
+
+	if *stack.StackStatus != cloudformation.StackStatusUpdateInProgress {
+		return errors.Errorf("stack %s is not being updated (status is %s)", stackName, *stack.StackStatus)
+	}
+
+	token := generateToken()
+	_, err = s.api.CancelUpdateStackWithContext(ctx, &cloudformation.CancelUpdateStackInput{
+		StackName:          stack.StackId,
+		ClientRequestToken: &token,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cancelling stack update")
+	}
+
+	_, err = s.PollStackEvents(ctx, *stack.StackId, token, func(event TailStackEvent) {
+		events <- event
+	})
+	return errors.Wrap(err, "waiting for rollback")
+}
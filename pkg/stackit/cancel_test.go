@@ -0,0 +1,45 @@
+package stackit
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func describeStacksOutput(status string) *cloudformation.DescribeStacksOutput {
+	return &cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{
+			StackId:     aws.String("arn:aws:cloudformation:ap-southeast-2:123456789012:stack/stack-name/abc"),
+			StackName:   aws.String("stack-name"),
+			StackStatus: aws.String(status),
+		}},
+	}
+}
+
+func TestCancelRefusesStackNotBeingUpdated(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateComplete), nil)
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.Cancel(context.Background(), "stack-name", make(chan TailStackEvent))
+	assert.EqualError(t, err, "stack stack-name is not being updated (status is UPDATE_COMPLETE)")
+	capi.AssertNotCalled(t, "CancelUpdateStackWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelSendsClientToken(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateInProgress), nil)
+	capi.On("CancelUpdateStackWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CancelUpdateStackInput)
+		assert.Equal(t, "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/stack-name/abc", *input.StackName)
+		assert.NotEmpty(t, *input.ClientRequestToken)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.Cancel(context.Background(), "stack-name", make(chan TailStackEvent))
+	assert.EqualError(t, err, "cancelling stack update: done")
+}
@@ -0,0 +1,132 @@
+package stackit
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/stackit/pkg/stackit/cfnyaml"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// DefaultCapabilities are granted when no capabilities are requested, which
+// preserves the behaviour of earlier versions of stackit.
+var DefaultCapabilities = []string{
+	cfnyaml.CapabilityIAM,
+	cfnyaml.CapabilityNamedIAM,
+	cfnyaml.CapabilityAutoExpand,
+}
+
+// InsufficientCapabilitiesError is returned when a template contains
+// resources that require capabilities that weren't granted.
+type InsufficientCapabilitiesError struct {
+	Missing []cfnyaml.CapabilityRequirement
+}
+
+func (e *InsufficientCapabilitiesError) Error() string {
+	reasons := []string{}
+	for _, req := range e.Missing {
+		if req.Type == "" {
+			reasons = append(reasons, fmt.Sprintf("%s requires %s", req.Resource, req.Capability))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("resource %s (%s) requires %s", req.Resource, req.Type, req.Capability))
+		}
+	}
+	return "capabilities not granted: " + strings.Join(reasons, "; ")
+}
+
+// InferCapabilities returns the capabilities required by the resources and
+// transforms in the template body.
+func InferCapabilities(templateBody string) ([]string, error) {
+	reqs, err := requiredCapabilities(templateBody)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := []string{}
+	for _, req := range reqs {
+		if !stringInSlice(caps, req.Capability) {
+			caps = append(caps, req.Capability)
+		}
+	}
+
+	// named iam resources are covered by CAPABILITY_NAMED_IAM alone
+	if stringInSlice(caps, cfnyaml.CapabilityNamedIAM) {
+		filtered := []string{}
+		for _, c := range caps {
+			if c != cfnyaml.CapabilityIAM {
+				filtered = append(filtered, c)
+			}
+		}
+		caps = filtered
+	}
+
+	sort.Strings(caps)
+	return caps, nil
+}
+
+// CheckCapabilities returns an *InsufficientCapabilitiesError naming every
+// resource in the template body that requires a capability not in granted.
+func CheckCapabilities(templateBody string, granted []string) error {
+	reqs, err := requiredCapabilities(templateBody)
+	if err != nil {
+		return err
+	}
+
+	missing := []cfnyaml.CapabilityRequirement{}
+	for _, req := range reqs {
+		if stringInSlice(granted, req.Capability) {
+			continue
+		}
+		if req.Capability == cfnyaml.CapabilityIAM && stringInSlice(granted, cfnyaml.CapabilityNamedIAM) {
+			continue
+		}
+		missing = append(missing, req)
+	}
+
+	if len(missing) > 0 {
+		return &InsufficientCapabilitiesError{Missing: missing}
+	}
+
+	return nil
+}
+
+func requiredCapabilities(templateBody string) ([]cfnyaml.CapabilityRequirement, error) {
+	template, err := cfnyaml.Parse([]byte(templateBody))
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+
+	return template.RequiredCapabilities()
+}
+
+// capabilities determines the capabilities to grant to the change set for
+// input. The template body is only needed when inferring or checking
+// capabilities, so the previous template is only fetched in those cases.
+func (s *Stackit) capabilities(ctx context.Context, input StackitUpInput) ([]string, error) {
+	if !input.InferCapabilities && input.Capabilities == nil {
+		return DefaultCapabilities, nil
+	}
+
+	var body string
+	if input.Template != nil {
+		body = input.Template.String()
+	} else {
+		resp, err := s.api.GetTemplateWithContext(ctx, &cloudformation.GetTemplateInput{
+			StackName:     &input.StackName,
+			TemplateStage: aws.String(cloudformation.TemplateStageOriginal),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "getting previous template body")
+		}
+		body = *resp.TemplateBody
+	}
+
+	if input.InferCapabilities {
+		return InferCapabilities(body)
+	}
+
+	return input.Capabilities, CheckCapabilities(body, input.Capabilities)
+}
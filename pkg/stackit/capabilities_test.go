@@ -0,0 +1,79 @@
+package stackit
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+const iamTemplate = `
+Resources:
+  Role:
+    Type: AWS::IAM::Role
+  NamedRole:
+    Type: AWS::IAM::Role
+    Properties:
+      RoleName: my-role
+`
+
+type stringTemplate string
+
+func (s stringTemplate) String() string { return string(s) }
+func (s stringTemplate) Name() string   { return "template.yml" }
+
+func TestInferCapabilities(t *testing.T) {
+	caps, err := InferCapabilities(iamTemplate)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CAPABILITY_NAMED_IAM"}, caps)
+
+	caps, err = InferCapabilities("Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n")
+	assert.NoError(t, err)
+	assert.Empty(t, caps)
+}
+
+func TestCheckCapabilitiesNamesResource(t *testing.T) {
+	err := CheckCapabilities(iamTemplate, []string{"CAPABILITY_IAM"})
+	assert.EqualError(t, err, "capabilities not granted: resource NamedRole (AWS::IAM::Role) requires CAPABILITY_NAMED_IAM")
+
+	err = CheckCapabilities(iamTemplate, []string{"CAPABILITY_NAMED_IAM"})
+	assert.NoError(t, err)
+}
+
+func TestPrepareInfersCapabilities(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CreateChangeSetInput)
+		assert.Equal(t, []string{"CAPABILITY_NAMED_IAM"}, aws.StringValueSlice(input.Capabilities))
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{
+		StackName:         "stack-name",
+		Template:          stringTemplate(iamTemplate),
+		InferCapabilities: true,
+	}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "creating change set: done")
+	capi.AssertCalled(t, "CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPrepareRejectsMissingCapabilities(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{
+		StackName:    "stack-name",
+		Template:     stringTemplate(iamTemplate),
+		Capabilities: []string{},
+	}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "determining capabilities: capabilities not granted: resource Role (AWS::IAM::Role) requires CAPABILITY_IAM; resource NamedRole (AWS::IAM::Role) requires CAPABILITY_NAMED_IAM")
+	capi.AssertNotCalled(t, "CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
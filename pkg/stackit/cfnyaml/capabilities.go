@@ -0,0 +1,83 @@
+package cfnyaml
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	CapabilityIAM        = "CAPABILITY_IAM"
+	CapabilityNamedIAM   = "CAPABILITY_NAMED_IAM"
+	CapabilityAutoExpand = "CAPABILITY_AUTO_EXPAND"
+)
+
+// iamResourceNameProperties maps IAM resource types to the property that
+// gives them a custom name, if they have one.
+var iamResourceNameProperties = map[string]string{
+	"AWS::IAM::AccessKey":           "",
+	"AWS::IAM::Group":               "GroupName",
+	"AWS::IAM::InstanceProfile":     "InstanceProfileName",
+	"AWS::IAM::ManagedPolicy":       "ManagedPolicyName",
+	"AWS::IAM::Policy":              "",
+	"AWS::IAM::Role":                "RoleName",
+	"AWS::IAM::User":                "UserName",
+	"AWS::IAM::UserToGroupAddition": "",
+}
+
+// serverlessRoleResourceTypes are SAM resource types that create an IAM role
+// unless one is provided in their Role property.
+var serverlessRoleResourceTypes = []string{
+	"AWS::Serverless::Function",
+	"AWS::Serverless::StateMachine",
+}
+
+// CapabilityRequirement records why a template requires a capability.
+// Resource is the logical ID of the resource that requires it, or
+// "Transform" for templates that use macros.
+type CapabilityRequirement struct {
+	Capability string
+	Resource   string
+	Type       string
+}
+
+// RequiredCapabilities scans the template for resources and transforms that
+// require capabilities to be acknowledged. Capabilities required only by
+// nested stacks can't be determined from the parent template.
+func (c *CfnYaml) RequiredCapabilities() ([]CapabilityRequirement, error) {
+	var reqs []CapabilityRequirement
+
+	if valueForKey(&c.Node, "Transform") != nil {
+		reqs = append(reqs, CapabilityRequirement{Capability: CapabilityAutoExpand, Resource: "Transform"})
+	}
+
+	resources := valueForKey(&c.Node, "Resources")
+	if resources == nil {
+		return nil, errors.New("no top-level key named `Resources` found in template")
+	}
+
+	for idx := 0; idx < len(resources.Content); idx += 2 {
+		name := resources.Content[idx].Value
+		valueNode := resources.Content[idx+1]
+
+		resTypeNode := valueForKey(valueNode, "Type")
+		if resTypeNode == nil {
+			return nil, errors.Errorf("resource `%s` has no `Type`", name)
+		}
+		resType := resTypeNode.Value
+
+		if nameProperty, ok := iamResourceNameProperties[resType]; ok {
+			capability := CapabilityIAM
+			if nameProperty != "" && valueForKey(valueNode, "Properties", nameProperty) != nil {
+				capability = CapabilityNamedIAM
+			}
+			reqs = append(reqs, CapabilityRequirement{Capability: capability, Resource: name, Type: resType})
+		}
+
+		for _, serverlessType := range serverlessRoleResourceTypes {
+			if resType == serverlessType && valueForKey(valueNode, "Properties", "Role") == nil {
+				reqs = append(reqs, CapabilityRequirement{Capability: CapabilityIAM, Resource: name, Type: resType})
+			}
+		}
+	}
+
+	return reqs, nil
+}
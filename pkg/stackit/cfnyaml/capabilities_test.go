@@ -0,0 +1,37 @@
+package cfnyaml
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCfnYaml_RequiredCapabilities(t *testing.T) {
+	c, err := Parse([]byte(`
+Transform: AWS::Serverless-2016-10-31
+Resources:
+  Role:
+    Type: AWS::IAM::Role
+  NamedRole:
+    Type: AWS::IAM::Role
+    Properties:
+      RoleName: my-role
+  Function:
+    Type: AWS::Serverless::Function
+  FunctionWithRole:
+    Type: AWS::Serverless::Function
+    Properties:
+      Role: !GetAtt Role.Arn
+  Bucket:
+    Type: AWS::S3::Bucket
+`))
+	assert.NoError(t, err)
+
+	reqs, err := c.RequiredCapabilities()
+	assert.NoError(t, err)
+	assert.Equal(t, []CapabilityRequirement{
+		{Capability: CapabilityAutoExpand, Resource: "Transform"},
+		{Capability: CapabilityIAM, Resource: "Role", Type: "AWS::IAM::Role"},
+		{Capability: CapabilityNamedIAM, Resource: "NamedRole", Type: "AWS::IAM::Role"},
+		{Capability: CapabilityIAM, Resource: "Function", Type: "AWS::Serverless::Function"},
+	}, reqs)
+}
@@ -81,6 +81,27 @@ func (c *CfnYaml) PackageableNodes() ([]PackageableNode, error) {
 	return nodes, nil
 }
 
+// ResourceTypes returns the type of each resource in the template, keyed by
+// logical ID.
+func (c *CfnYaml) ResourceTypes() (map[string]string, error) {
+	resources := valueForKey(&c.Node, "Resources")
+	if resources == nil {
+		return nil, errors.New("no top-level key named `Resources` found in template")
+	}
+
+	types := map[string]string{}
+	for idx := 0; idx < len(resources.Content); idx += 2 {
+		name := resources.Content[idx].Value
+		resTypeNode := valueForKey(resources.Content[idx+1], "Type")
+		if resTypeNode == nil {
+			return nil, errors.Errorf("resource `%s` has no `Type`", name)
+		}
+		types[name] = resTypeNode.Value
+	}
+
+	return types, nil
+}
+
 func looksPackageable(n *yaml.Node, def *packageablePropertyDefinition) bool {
 	// TODO: https://github.com/glassechidna/stackit/issues/34
 	return n.Kind == yaml.ScalarNode
@@ -86,3 +86,21 @@ func TestCfnYaml_PackageableNodes(t *testing.T) {
 		})
 	}
 }
+
+func TestCfnYaml_ResourceTypes(t *testing.T) {
+	c, err := Parse([]byte(`
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+  AVeryLongLogicalIdForATopicResource:
+    Type: AWS::SNS::Topic
+`))
+	assert.NoError(t, err)
+
+	types, err := c.ResourceTypes()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Queue":                               "AWS::SQS::Queue",
+		"AVeryLongLogicalIdForATopicResource": "AWS::SNS::Topic",
+	}, types)
+}
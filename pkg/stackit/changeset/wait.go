@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/glassechidna/awsctx/service/cloudformationctx"
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
 	"github.com/pkg/errors"
 	"time"
 )
@@ -17,9 +18,12 @@ func (e *NoOpChangesetError) Error() string {
 	return errNoOp
 }
 
-func Wait(ctx context.Context, api cloudformationctx.CloudFormation, id string) (*cloudformation.DescribeChangeSetOutput, error) {
+// Wait polls the change set until it has been created, backing off
+// according to policy while throttled.
+func Wait(ctx context.Context, api cloudformationctx.CloudFormation, id string, policy retry.Policy) (*cloudformation.DescribeChangeSetOutput, error) {
 	status := "CREATE_PENDING"
 	terminal := []string{"CREATE_COMPLETE", "DELETE_COMPLETE", "FAILED"}
+	poller := policy.Poller(2 * time.Second)
 
 	var resp *cloudformation.DescribeChangeSetOutput
 	var err error
@@ -27,9 +31,12 @@ func Wait(ctx context.Context, api cloudformationctx.CloudFormation, id string)
 	for !stringInSlice(terminal, status) {
 		resp, err = api.DescribeChangeSetWithContext(ctx, &cloudformation.DescribeChangeSetInput{
 			ChangeSetName: &id,
-		})
+		}, retry.WithoutThrottlingRetries)
 		if err != nil {
-			return resp, errors.Wrap(err, "describing change set")
+			if err = poller.Wait(ctx, err); err != nil {
+				return resp, errors.Wrap(err, "describing change set")
+			}
+			continue
 		}
 
 		status = *resp.Status
@@ -46,7 +53,13 @@ func Wait(ctx context.Context, api cloudformationctx.CloudFormation, id string)
 			}
 		}
 
-		time.Sleep(2 * time.Second)
+		if stringInSlice(terminal, status) {
+			break
+		}
+
+		if err = poller.Wait(ctx, nil); err != nil {
+			return resp, err
+		}
 	}
 
 	return resp, nil
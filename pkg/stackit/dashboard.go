@@ -0,0 +1,281 @@
+package stackit
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/fatih/color"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MinDashboardHeight is the fewest lines a terminal can have for a dashboard
+// to be drawn in it: a header, the stack, a line saying how many resources
+// are hidden, and the line the cursor is left on.
+const MinDashboardHeight = 4
+
+var dashboardSpinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Dashboard shows the current status of every resource in a stack as a
+// table that is redrawn in place, for use in terminals instead of a
+// TailPrinter.
+type Dashboard struct {
+	writer       io.Writer
+	width        int
+	height       int
+	failureColor *color.Color
+	stackName    string
+	rows         map[string]*dashboardRow
+	drawn        int
+	frame        int
+	changed      bool
+}
+
+type dashboardRow struct {
+	name     string
+	typ      string
+	status   string
+	reason   string
+	started  time.Time
+	finished time.Time
+}
+
+// NewDashboard returns a dashboard that draws to writer, a terminal that is
+// width columns wide and height lines high (or unlimited if zero). resources
+// are the logical IDs and types of resources known to be in the stack, which
+// are shown before any of their events arrive.
+func NewDashboard(writer io.Writer, resources map[string]string, width, height int) *Dashboard {
+	d := &Dashboard{
+		writer:       writer,
+		width:        width,
+		height:       height,
+		failureColor: color.New(color.FgRed),
+		rows:         map[string]*dashboardRow{},
+	}
+
+	for name, typ := range resources {
+		d.rows[name] = &dashboardRow{name: name, typ: typ}
+	}
+
+	return d
+}
+
+// Update records the status of the event's resource.
+func (d *Dashboard) Update(event TailStackEvent) {
+	name := strings.Join(append(append([]string{}, event.NestedPath...), aws.StringValue(event.LogicalResourceId)), "/")
+	if d.stackName == "" && len(event.NestedPath) == 0 {
+		d.stackName = aws.StringValue(event.StackName)
+	}
+
+	row, ok := d.rows[name]
+	if !ok {
+		row = &dashboardRow{name: name}
+		d.rows[name] = row
+	}
+
+	status := aws.StringValue(event.ResourceStatus)
+	timestamp := aws.TimeValue(event.Timestamp)
+	if isInProgress(status) && !isInProgress(row.status) {
+		row.started = timestamp
+	}
+	if !isInProgress(status) {
+		row.finished = timestamp
+	}
+
+	row.typ = aws.StringValue(event.ResourceType)
+	row.status = status
+	row.reason = ""
+	if isBadStatus(status) {
+		row.reason = aws.StringValue(event.ResourceStatusReason)
+	}
+
+	d.changed = true
+}
+
+// Draw redraws the table over the previous one. Nothing is drawn until the
+// first event has arrived, or if nothing has changed since the last draw.
+// Once the stack's operation has finished, the table is left as it is and
+// the next one is drawn below it.
+func (d *Dashboard) Draw(now time.Time) {
+	if d.stackName == "" || (!d.changed && (d.finished() || !d.inProgress())) {
+		return
+	}
+
+	lines := d.Render(now)
+	d.frame++
+	d.changed = false
+
+	sb := &strings.Builder{}
+	if d.drawn > 0 {
+		fmt.Fprintf(sb, "\x1b[%dA", d.drawn)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(sb, "\x1b[2K%s\n", line)
+	}
+	for idx := len(lines); idx < d.drawn; idx++ {
+		sb.WriteString("\x1b[2K\n")
+	}
+	if len(lines) < d.drawn {
+		fmt.Fprintf(sb, "\x1b[%dA", d.drawn-len(lines))
+	}
+
+	fmt.Fprint(d.writer, sb.String())
+	d.drawn = len(lines)
+
+	// anything printed between operations (e.g. a change set to confirm)
+	// would be drawn over, so the next operation gets a table of its own
+	if d.finished() {
+		d.drawn = 0
+	}
+}
+
+// Render returns the lines of the table as of now. The stack itself is
+// listed first, followed by its resources in alphabetical order. A table too
+// tall for the terminal couldn't be redrawn in place, so resources that
+// aren't in progress or failed are hidden until it fits.
+func (d *Dashboard) Render(now time.Time) []string {
+	rows := []*dashboardRow{}
+	for _, row := range d.rows {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if (rows[i].name == d.stackName) != (rows[j].name == d.stackName) {
+			return rows[i].name == d.stackName
+		}
+		return rows[i].name < rows[j].name
+	})
+
+	nameLength, typeLength, statusLength := len("Resource"), len("Type"), len("Status")
+	for _, row := range rows {
+		nameLength = maxInt(nameLength, len(row.name))
+		typeLength = maxInt(typeLength, len(row.typ))
+		statusLength = maxInt(statusLength, len(row.status)+2)
+	}
+
+	hidden := 0
+	if d.height > 0 && len(rows)+2 > d.height {
+		visible := d.visibleRows(rows, d.height-3)
+		hidden = len(rows) - len(visible)
+		rows = visible
+	}
+
+	format := fmt.Sprintf("%%-%ds  %%-%ds  %%-%ds  %%s", nameLength, typeLength, statusLength)
+	lines := []string{d.truncate(fmt.Sprintf(format, "Resource", "Type", "Status", "Elapsed"))}
+
+	for _, row := range rows {
+		status, elapsed := "  -", ""
+		if row.status != "" {
+			marker := " "
+			if isInProgress(row.status) {
+				marker = dashboardSpinner[d.frame%len(dashboardSpinner)]
+			}
+			status = fmt.Sprintf("%s %s", marker, row.status)
+			elapsed = row.elapsed(now).String()
+		}
+
+		line := strings.TrimRight(fmt.Sprintf(format, row.name, row.typ, status, elapsed), " ")
+		if row.reason != "" {
+			line = fmt.Sprintf("%s  %s", line, row.reason)
+		}
+
+		line = d.truncate(line)
+		if isBadStatus(row.status) && d.failureColor != nil {
+			line = d.failureColor.Sprint(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if hidden > 0 {
+		lines = append(lines, d.truncate(fmt.Sprintf("+%d more", hidden)))
+	}
+
+	return lines
+}
+
+// visibleRows picks n of the sorted rows to show, preferring the stack
+// itself, then resources in progress, then failed resources. The rows keep
+// their order.
+func (d *Dashboard) visibleRows(rows []*dashboardRow, n int) []*dashboardRow {
+	priority := func(row *dashboardRow) int {
+		switch {
+		case row.name == d.stackName:
+			return 0
+		case isInProgress(row.status):
+			return 1
+		case isBadStatus(row.status):
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	order := make([]int, len(rows))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return priority(rows[order[i]]) < priority(rows[order[j]])
+	})
+
+	if n < 1 {
+		n = 1
+	}
+	if n < len(order) {
+		order = order[:n]
+	}
+	sort.Ints(order)
+
+	visible := []*dashboardRow{}
+	for _, idx := range order {
+		visible = append(visible, rows[idx])
+	}
+	return visible
+}
+
+// finished reports whether the stack's operation has finished.
+func (d *Dashboard) finished() bool {
+	stack, ok := d.rows[d.stackName]
+	return ok && IsTerminalStatus(stack.status)
+}
+
+func (d *Dashboard) inProgress() bool {
+	for _, row := range d.rows {
+		if isInProgress(row.status) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncate shortens line to the width of the terminal, as wrapped lines
+// would throw out the count of lines to redraw.
+func (d *Dashboard) truncate(line string) string {
+	runes := []rune(line)
+	if d.width <= 0 || len(runes) < d.width {
+		return line
+	}
+	return string(runes[:d.width-1])
+}
+
+func (r *dashboardRow) elapsed(now time.Time) time.Duration {
+	end := r.finished
+	if isInProgress(r.status) || end.Before(r.started) {
+		end = now
+	}
+	if r.started.IsZero() {
+		return 0
+	}
+	return end.Sub(r.started).Truncate(time.Second)
+}
+
+func isInProgress(status string) bool {
+	return strings.HasSuffix(status, "_IN_PROGRESS")
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
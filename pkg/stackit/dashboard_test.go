@@ -0,0 +1,109 @@
+package stackit
+
+import (
+	"bytes"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboard(t *testing.T) {
+	start := time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)
+	event := func(path []string, logicalId, typ, status, reason string, offset time.Duration) TailStackEvent {
+		return TailStackEvent{
+			StackEvent: cloudformation.StackEvent{
+				StackName:            aws.String("stack-name"),
+				LogicalResourceId:    aws.String(logicalId),
+				ResourceType:         aws.String(typ),
+				ResourceStatus:       aws.String(status),
+				ResourceStatusReason: aws.String(reason),
+				Timestamp:            aws.Time(start.Add(offset)),
+			},
+			NestedPath: path,
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	d := NewDashboard(buf, map[string]string{
+		"stack-name": "AWS::CloudFormation::Stack",
+		"Queue":      "AWS::SQS::Queue",
+		"Bucket":     "AWS::S3::Bucket",
+	}, 120, 0)
+	d.failureColor = nil
+
+	d.Draw(start)
+	assert.Empty(t, buf.String(), "nothing is drawn before the first event")
+
+	d.Update(event(nil, "stack-name", "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated", 0))
+	d.Update(event(nil, "Queue", "AWS::SQS::Queue", "UPDATE_IN_PROGRESS", "", time.Second))
+	d.Update(event([]string{"Child"}, "Topic", "AWS::SNS::Topic", "CREATE_FAILED", "topic exists", 2*time.Second))
+
+	assert.Equal(t, []string{
+		"Resource     Type                        Status                Elapsed",
+		"stack-name   AWS::CloudFormation::Stack  ⠋ UPDATE_IN_PROGRESS  10s",
+		"Bucket       AWS::S3::Bucket               -",
+		"Child/Topic  AWS::SNS::Topic               CREATE_FAILED       0s  topic exists",
+		"Queue        AWS::SQS::Queue             ⠋ UPDATE_IN_PROGRESS  9s",
+	}, d.Render(start.Add(10*time.Second)))
+
+	d.Draw(start.Add(10 * time.Second))
+	assert.Equal(t, 5, strings.Count(buf.String(), "\x1b[2K"))
+	assert.NotContains(t, buf.String(), "\x1b[5A")
+
+	d.Update(event(nil, "Queue", "AWS::SQS::Queue", "UPDATE_COMPLETE", "", 4*time.Second))
+	d.Update(event(nil, "stack-name", "AWS::CloudFormation::Stack", "UPDATE_COMPLETE", "", 5*time.Second))
+
+	buf.Reset()
+	d.Draw(start.Add(20 * time.Second))
+	assert.True(t, strings.HasPrefix(buf.String(), "\x1b[5A"), "the table is redrawn in place")
+	assert.Contains(t, buf.String(), "Queue        AWS::SQS::Queue               UPDATE_COMPLETE  3s\n")
+
+	buf.Reset()
+	d.Draw(start.Add(30 * time.Second))
+	assert.Empty(t, buf.String(), "finished operations aren't redrawn")
+}
+
+func TestDashboardTruncatesToWidth(t *testing.T) {
+	d := NewDashboard(nil, nil, 10, 0)
+	assert.Equal(t, "012345678", d.truncate("0123456789abc"))
+	assert.Equal(t, "short", d.truncate("short"))
+}
+
+func TestDashboardFitsHeight(t *testing.T) {
+	start := time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)
+	event := func(logicalId, status string) TailStackEvent {
+		return TailStackEvent{StackEvent: cloudformation.StackEvent{
+			StackName:         aws.String("stack-name"),
+			LogicalResourceId: aws.String(logicalId),
+			ResourceType:      aws.String("AWS::SQS::Queue"),
+			ResourceStatus:    aws.String(status),
+			Timestamp:         aws.Time(start),
+		}}
+	}
+
+	resources := map[string]string{"stack-name": "AWS::CloudFormation::Stack"}
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		resources[name] = "AWS::SQS::Queue"
+	}
+
+	buf := &bytes.Buffer{}
+	d := NewDashboard(buf, resources, 120, 6)
+	d.failureColor = nil
+
+	d.Update(event("stack-name", "UPDATE_IN_PROGRESS"))
+	d.Update(event("E", "UPDATE_IN_PROGRESS"))
+	d.Update(event("C", "UPDATE_FAILED"))
+
+	lines := d.Render(start)
+	assert.Len(t, lines, 5, "one line is left for the cursor")
+	assert.Contains(t, lines[1], "stack-name")
+	assert.Contains(t, lines[2], "C ")
+	assert.Contains(t, lines[3], "E ")
+	assert.Equal(t, "+4 more", lines[4])
+
+	d.Draw(start)
+	assert.Equal(t, 5, strings.Count(buf.String(), "\x1b[2K"))
+}
@@ -10,6 +10,10 @@ func (s *Stackit) Down(ctx context.Context, stackName string, events chan<- Tail
 	stack, err := s.Describe(ctx, stackName)
 
 	if stack != nil { // stack exists
+		if stack.EnableTerminationProtection != nil && *stack.EnableTerminationProtection {
+			return &TerminationProtectedError{StackName: stackName}
+		}
+
 		token := generateToken()
 
 		input := &cloudformation.DeleteStackInput{
@@ -21,7 +25,9 @@ func (s *Stackit) Down(ctx context.Context, stackName string, events chan<- Tail
 			return err
 		}
 
+		record := s.recordEvents(*stack.StackId)
 		finalEvent, err := s.PollStackEvents(ctx, *stack.StackId, token, func(event TailStackEvent) {
+			record(event)
 			events <- event
 		})
 		if err != nil {
@@ -42,6 +48,7 @@ func (s *Stackit) Down(ctx context.Context, stackName string, events chan<- Tail
 			}
 
 			_, err = s.PollStackEvents(ctx, *stack.StackId, token, func(event TailStackEvent) {
+				record(event)
 				events <- event
 			})
 			if err != nil {
@@ -54,11 +61,15 @@ func (s *Stackit) Down(ctx context.Context, stackName string, events chan<- Tail
 }
 
 func (s *Stackit) resourcesToBeRetainedDuringDelete(ctx context.Context, stackName string, events chan<- TailStackEvent) ([]*string, error) {
+	return s.resourcesInStatus(ctx, stackName, cloudformation.ResourceStatusDeleteFailed)
+}
+
+func (s *Stackit) resourcesInStatus(ctx context.Context, stackName, status string) ([]*string, error) {
 	var names []*string
 
 	err := s.api.ListStackResourcesPagesWithContext(ctx, &cloudformation.ListStackResourcesInput{StackName: &stackName}, func(page *cloudformation.ListStackResourcesOutput, lastPage bool) bool {
 		for _, resource := range page.StackResourceSummaries {
-			if *resource.ResourceStatus == cloudformation.ResourceStatusDeleteFailed {
+			if *resource.ResourceStatus == status {
 				names = append(names, resource.LogicalResourceId)
 			}
 		}
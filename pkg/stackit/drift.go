@@ -0,0 +1,138 @@
+package stackit
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/stackit/pkg/stackit/drift"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+type DriftReport struct {
+	StackName string          `json:"stackName"`
+	StackId   string          `json:"stackId"`
+	Status    string          `json:"status"`
+	Resources []ResourceDrift `json:"resources"`
+}
+
+type ResourceDrift struct {
+	LogicalId   string               `json:"logicalId"`
+	PhysicalId  string               `json:"physicalId"`
+	Type        string               `json:"type"`
+	Status      string               `json:"status"`
+	Differences []PropertyDifference `json:"differences,omitempty"`
+}
+
+type PropertyDifference struct {
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Resources) > 0
+}
+
+// DetectDrift runs drift detection on the stack, waits for it to complete
+// and returns the resources that have been modified or deleted outside of
+// CloudFormation.
+func (s *Stackit) DetectDrift(ctx context.Context, stackName string) (*DriftReport, error) {
+	stack, err := s.Describe(ctx, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if stack == nil {
+		return nil, errors.Errorf("stack %s does not exist", stackName)
+	}
+
+	detectResp, err := s.api.DetectStackDriftWithContext(ctx, &cloudformation.DetectStackDriftInput{StackName: stack.StackId})
+	if err != nil {
+		return nil, errors.Wrap(err, "detecting stack drift")
+	}
+
+	status, err := drift.Wait(ctx, s.api, *detectResp.StackDriftDetectionId, s.retry)
+	if err != nil {
+		return nil, errors.Wrap(err, "waiting for drift detection")
+	}
+
+	report := &DriftReport{
+		StackName: stackName,
+		StackId:   *stack.StackId,
+		Status:    aws.StringValue(status.StackDriftStatus),
+		Resources: []ResourceDrift{},
+	}
+
+	input := &cloudformation.DescribeStackResourceDriftsInput{
+		StackName: stack.StackId,
+		StackResourceDriftStatusFilters: aws.StringSlice([]string{
+			cloudformation.StackResourceDriftStatusModified,
+			cloudformation.StackResourceDriftStatusDeleted,
+		}),
+	}
+	err = s.api.DescribeStackResourceDriftsPagesWithContext(ctx, input, func(page *cloudformation.DescribeStackResourceDriftsOutput, lastPage bool) bool {
+		for _, d := range page.StackResourceDrifts {
+			rd := ResourceDrift{
+				LogicalId:  aws.StringValue(d.LogicalResourceId),
+				PhysicalId: aws.StringValue(d.PhysicalResourceId),
+				Type:       aws.StringValue(d.ResourceType),
+				Status:     aws.StringValue(d.StackResourceDriftStatus),
+			}
+
+			for _, diff := range d.PropertyDifferences {
+				rd.Differences = append(rd.Differences, PropertyDifference{
+					Path:     aws.StringValue(diff.PropertyPath),
+					Type:     aws.StringValue(diff.DifferenceType),
+					Expected: aws.StringValue(diff.ExpectedValue),
+					Actual:   aws.StringValue(diff.ActualValue),
+				})
+			}
+
+			report.Resources = append(report.Resources, rd)
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describing stack resource drifts")
+	}
+
+	return report, nil
+}
+
+// DriftedChangesError is returned by Prepare when FailOnDrift is set and the
+// change set would change resources that have drifted.
+type DriftedChangesError struct {
+	StackName string
+	Resources []ResourceDrift
+}
+
+func (e *DriftedChangesError) Error() string {
+	names := []string{}
+	for _, resource := range e.Resources {
+		names = append(names, fmt.Sprintf("%s (%s)", resource.LogicalId, resource.Status))
+	}
+	return fmt.Sprintf("stack %s has drifted resources that the change set would change: %s", e.StackName, strings.Join(names, ", "))
+}
+
+// driftedChanges returns the drifted resources that are also changed by the
+// change set.
+func driftedChanges(report *DriftReport, changes []*cloudformation.Change) []ResourceDrift {
+	changed := map[string]bool{}
+	for _, change := range changes {
+		if change.ResourceChange != nil {
+			changed[aws.StringValue(change.ResourceChange.LogicalResourceId)] = true
+		}
+	}
+
+	drifted := []ResourceDrift{}
+	for _, resource := range report.Resources {
+		if changed[resource.LogicalId] {
+			drifted = append(drifted, resource)
+		}
+	}
+
+	return drifted
+}
@@ -0,0 +1,40 @@
+package drift
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/awsctx/service/cloudformationctx"
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Wait polls the drift detection until it has finished, backing off
+// according to policy while throttled.
+func Wait(ctx context.Context, api cloudformationctx.CloudFormation, id string, policy retry.Policy) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error) {
+	poller := policy.Poller(2 * time.Second)
+
+	for {
+		resp, err := api.DescribeStackDriftDetectionStatusWithContext(ctx, &cloudformation.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: &id,
+		}, retry.WithoutThrottlingRetries)
+		if err != nil {
+			if err = poller.Wait(ctx, err); err != nil {
+				return resp, errors.Wrap(err, "describing drift detection status")
+			}
+			continue
+		}
+
+		switch *resp.DetectionStatus {
+		case cloudformation.StackDriftDetectionStatusDetectionComplete:
+			return resp, nil
+		case cloudformation.StackDriftDetectionStatusDetectionFailed:
+			return nil, errors.New(aws.StringValue(resp.DetectionStatusReason))
+		}
+
+		if err = poller.Wait(ctx, nil); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestDetectDrift(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateComplete), nil)
+	capi.On("DetectStackDriftWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DetectStackDriftOutput{StackDriftDetectionId: aws.String("detection-id")}, nil)
+	capi.On("DescribeStackDriftDetectionStatusWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeStackDriftDetectionStatusOutput{
+		DetectionStatus:  aws.String(cloudformation.StackDriftDetectionStatusDetectionComplete),
+		StackDriftStatus: aws.String(cloudformation.StackDriftStatusDrifted),
+	}, nil)
+	capi.On("DescribeStackResourceDriftsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.DescribeStackResourceDriftsInput)
+		assert.Equal(t, []string{"MODIFIED", "DELETED"}, aws.StringValueSlice(input.StackResourceDriftStatusFilters))
+
+		cb := args.Get(2).(func(*cloudformation.DescribeStackResourceDriftsOutput, bool) bool)
+		cb(&cloudformation.DescribeStackResourceDriftsOutput{
+			StackResourceDrifts: []*cloudformation.StackResourceDrift{
+				{
+					LogicalResourceId:        aws.String("Queue"),
+					PhysicalResourceId:       aws.String("https://sqs/queue"),
+					ResourceType:             aws.String("AWS::SQS::Queue"),
+					StackResourceDriftStatus: aws.String(cloudformation.StackResourceDriftStatusModified),
+					PropertyDifferences: []*cloudformation.PropertyDifference{
+						{PropertyPath: aws.String("/VisibilityTimeout"), DifferenceType: aws.String("NOT_EQUAL"), ExpectedValue: aws.String("30"), ActualValue: aws.String("60")},
+					},
+				},
+			},
+		}, true)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	report, err := s.DetectDrift(context.Background(), "stack-name")
+	assert.NoError(t, err)
+	assert.True(t, report.HasDrift())
+	assert.Equal(t, "DRIFTED", report.Status)
+	assert.Equal(t, []ResourceDrift{{
+		LogicalId:  "Queue",
+		PhysicalId: "https://sqs/queue",
+		Type:       "AWS::SQS::Queue",
+		Status:     "MODIFIED",
+		Differences: []PropertyDifference{
+			{Path: "/VisibilityTimeout", Type: "NOT_EQUAL", Expected: "30", Actual: "60"},
+		},
+	}}, report.Resources)
+}
+
+func TestPrepareFailsOnDriftedChanges(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateComplete), nil)
+	capi.On("DetectStackDriftWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DetectStackDriftOutput{StackDriftDetectionId: aws.String("detection-id")}, nil)
+	capi.On("DescribeStackDriftDetectionStatusWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeStackDriftDetectionStatusOutput{
+		DetectionStatus:  aws.String(cloudformation.StackDriftDetectionStatusDetectionComplete),
+		StackDriftStatus: aws.String(cloudformation.StackDriftStatusDrifted),
+	}, nil)
+	capi.On("DescribeStackResourceDriftsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		cb := args.Get(2).(func(*cloudformation.DescribeStackResourceDriftsOutput, bool) bool)
+		cb(&cloudformation.DescribeStackResourceDriftsOutput{
+			StackResourceDrifts: []*cloudformation.StackResourceDrift{
+				{LogicalResourceId: aws.String("Queue"), StackResourceDriftStatus: aws.String("MODIFIED")},
+				{LogicalResourceId: aws.String("Topic"), StackResourceDriftStatus: aws.String("MODIFIED")},
+			},
+		}, true)
+	})
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.CreateChangeSetOutput{
+		Id:      aws.String("change-set-id"),
+		StackId: aws.String("stack-id"),
+	}, nil)
+	capi.On("DescribeChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeChangeSetOutput{
+		Status: aws.String("CREATE_COMPLETE"),
+		Changes: []*cloudformation.Change{
+			{ResourceChange: &cloudformation.ResourceChange{LogicalResourceId: aws.String("Queue")}},
+			{ResourceChange: &cloudformation.ResourceChange{LogicalResourceId: aws.String("Bucket")}},
+		},
+	}, nil)
+	capi.On("GetTemplateWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.GetTemplateOutput{TemplateBody: aws.String("{}")}, nil)
+	capi.On("DeleteChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DeleteChangeSetOutput{}, nil)
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{StackName: "stack-name", PreviousTemplate: true, FailOnDrift: true}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "stack stack-name has drifted resources that the change set would change: Queue (MODIFIED)")
+	capi.AssertCalled(t, "DeleteChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
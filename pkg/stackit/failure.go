@@ -0,0 +1,241 @@
+package stackit
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// FailureSummary explains why a stack operation failed: which resources
+// failed first, and how the rollback that followed turned out.
+type FailureSummary struct {
+	StackName string `json:"stackName"`
+	StackId   string `json:"stackId"`
+	Status    string `json:"status"`
+
+	// RootCause is the failure that most likely caused the others, as
+	// determined by RootCause.
+	RootCause *ResourceFailure `json:"rootCause,omitempty"`
+
+	// Failures are the resources that failed before the stack started rolling
+	// back, in the order they failed. Nested stack resources are omitted when
+	// resources inside them failed.
+	Failures []ResourceFailure `json:"failures"`
+
+	// RollbackFailures are the resources that failed while rolling back.
+	RollbackFailures []ResourceFailure `json:"rollbackFailures,omitempty"`
+}
+
+type ResourceFailure struct {
+	NestedPath []string  `json:"nestedPath,omitempty"`
+	LogicalId  string    `json:"logicalId"`
+	PhysicalId string    `json:"physicalId,omitempty"`
+	Type       string    `json:"resourceType"`
+	Status     string    `json:"status"`
+	Reason     string    `json:"reason"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+func (r ResourceFailure) String() string {
+	name := strings.Join(append(append([]string{}, r.NestedPath...), r.LogicalId), "/")
+	return fmt.Sprintf("%s (%s) %s: %s", name, r.Type, r.Status, r.Reason)
+}
+
+// String formats the summary as a block of text for people to read.
+func (f *FailureSummary) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "Stack %s failed with status %s.\n", f.StackName, f.Status)
+
+	if f.RootCause != nil {
+		fmt.Fprintf(sb, "\nRoot cause:\n  %s\n", f.RootCause)
+	}
+
+	if len(f.Failures) > 0 {
+		fmt.Fprintf(sb, "\nFirst failures:\n")
+		for _, failure := range f.Failures {
+			fmt.Fprintf(sb, "  %s\n", failure)
+		}
+	}
+
+	if len(f.RollbackFailures) > 0 {
+		fmt.Fprintf(sb, "\nFailed to roll back:\n")
+		for _, failure := range f.RollbackFailures {
+			fmt.Fprintf(sb, "  %s\n", failure)
+		}
+	} else if strings.Contains(f.Status, "ROLLBACK") {
+		fmt.Fprintf(sb, "\nRollback finished with status %s.\n", f.Status)
+	}
+
+	return sb.String()
+}
+
+// FailureSummary summarises the most recent operation on the stack. The
+// events received while executing a change set or deleting the stack are
+// used if that operation was performed by s, including those of nested
+// stacks. Otherwise the stack's own events are fetched from the API.
+func (s *Stackit) FailureSummary(ctx context.Context, stackId string) (*FailureSummary, error) {
+	stack, err := s.Describe(ctx, stackId)
+	if err != nil {
+		return nil, err
+	}
+	if stack == nil {
+		return nil, errors.Errorf("stack %s does not exist", stackId)
+	}
+
+	events := s.recordedEvents(*stack.StackId)
+	if events == nil {
+		events, err = s.lastOperationEvents(ctx, *stack.StackId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewFailureSummary(stack, events), nil
+}
+
+// NewFailureSummary summarises the failures in events, which are the
+// events of a single operation on stack in the order they happened.
+func NewFailureSummary(stack *cloudformation.Stack, events []TailStackEvent) *FailureSummary {
+	summary := &FailureSummary{
+		StackName: aws.StringValue(stack.StackName),
+		StackId:   aws.StringValue(stack.StackId),
+		Status:    aws.StringValue(stack.StackStatus),
+		Failures:  []ResourceFailure{},
+	}
+
+	if cause := RootCause(events); cause != nil {
+		failure := newResourceFailure(*cause)
+		summary.RootCause = &failure
+	}
+
+	rollingBack := false
+	for _, event := range events {
+		stackLevel := aws.StringValue(event.PhysicalResourceId) == aws.StringValue(event.StackId)
+		if stackLevel && len(event.NestedPath) == 0 && strings.HasSuffix(aws.StringValue(event.ResourceStatus), "ROLLBACK_IN_PROGRESS") {
+			rollingBack = true
+		}
+
+		if stackLevel || !isBadStatus(aws.StringValue(event.ResourceStatus)) || isCancellation(event) {
+			continue
+		}
+
+		if rollingBack {
+			summary.RollbackFailures = append(summary.RollbackFailures, newResourceFailure(event))
+		} else if !failedWithin(event, events) {
+			summary.Failures = append(summary.Failures, newResourceFailure(event))
+		}
+	}
+
+	return summary
+}
+
+func newResourceFailure(event TailStackEvent) ResourceFailure {
+	return ResourceFailure{
+		NestedPath: event.NestedPath,
+		LogicalId:  aws.StringValue(event.LogicalResourceId),
+		PhysicalId: aws.StringValue(event.PhysicalResourceId),
+		Type:       aws.StringValue(event.ResourceType),
+		Status:     aws.StringValue(event.ResourceStatus),
+		Reason:     aws.StringValue(event.ResourceStatusReason),
+		Timestamp:  aws.TimeValue(event.Timestamp),
+	}
+}
+
+// isCancellation reports whether the event is a resource operation that was
+// cancelled because another resource failed.
+func isCancellation(event TailStackEvent) bool {
+	return strings.HasSuffix(aws.StringValue(event.ResourceStatusReason), " cancelled")
+}
+
+// failedWithin reports whether event is the failure of a nested stack
+// resource in which other resources failed, which are more useful to report.
+// Nested stacks report their own failures before their parents do, so all
+// events are searched.
+func failedWithin(event TailStackEvent, events []TailStackEvent) bool {
+	if aws.StringValue(event.ResourceType) != nestedStackResourceType {
+		return false
+	}
+
+	path := append(append([]string{}, event.NestedPath...), *event.LogicalResourceId)
+	for _, other := range events {
+		if isBadStatus(aws.StringValue(other.ResourceStatus)) && hasPathPrefix(other.NestedPath, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+
+	for idx := range prefix {
+		if path[idx] != prefix[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// lastOperationEvents fetches the stack's events back to the start of its
+// most recent operation, oldest first.
+func (s *Stackit) lastOperationEvents(ctx context.Context, stackId string) ([]TailStackEvent, error) {
+	events := []TailStackEvent{}
+
+	err := s.api.DescribeStackEventsPagesWithContext(ctx, &cloudformation.DescribeStackEventsInput{StackName: &stackId}, func(page *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+		for _, event := range page.StackEvents {
+			events = append([]TailStackEvent{{StackEvent: *event}}, events...)
+
+			if aws.StringValue(event.PhysicalResourceId) != aws.StringValue(event.StackId) {
+				continue
+			}
+
+			switch aws.StringValue(event.ResourceStatus) {
+			case
+				cloudformation.ResourceStatusCreateInProgress,
+				cloudformation.ResourceStatusUpdateInProgress,
+				cloudformation.ResourceStatusDeleteInProgress,
+				cloudformation.ResourceStatusImportInProgress:
+				return false
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describing stack events")
+	}
+
+	return events, nil
+}
+
+// recordEvents starts recording the events of an operation on the stack,
+// replacing those of any previous operation. The returned function records
+// an event.
+func (s *Stackit) recordEvents(stackId string) func(event TailStackEvent) {
+	s.recordedMu.Lock()
+	defer s.recordedMu.Unlock()
+
+	if s.recorded == nil {
+		s.recorded = map[string][]TailStackEvent{}
+	}
+	s.recorded[stackId] = []TailStackEvent{}
+
+	return func(event TailStackEvent) {
+		s.recordedMu.Lock()
+		defer s.recordedMu.Unlock()
+		s.recorded[stackId] = append(s.recorded[stackId], event)
+	}
+}
+
+func (s *Stackit) recordedEvents(stackId string) []TailStackEvent {
+	s.recordedMu.Lock()
+	defer s.recordedMu.Unlock()
+	return s.recorded[stackId]
+}
@@ -0,0 +1,112 @@
+package stackit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func failureTestEvent(path []string, stackId, logicalId, physicalId, typ, status, reason string) TailStackEvent {
+	return TailStackEvent{
+		StackEvent: cloudformation.StackEvent{
+			StackId:              aws.String(stackId),
+			LogicalResourceId:    aws.String(logicalId),
+			PhysicalResourceId:   aws.String(physicalId),
+			ResourceType:         aws.String(typ),
+			ResourceStatus:       aws.String(status),
+			ResourceStatusReason: aws.String(reason),
+			Timestamp:            aws.Time(time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)),
+		},
+		NestedPath: path,
+	}
+}
+
+func TestNewFailureSummary(t *testing.T) {
+	child := []string{"Child"}
+	events := []TailStackEvent{
+		failureTestEvent(nil, "parent", "parent", "parent", "AWS::CloudFormation::Stack", "UPDATE_IN_PROGRESS", "User Initiated"),
+		failureTestEvent(child, "child", "Queue", "", "AWS::SQS::Queue", "CREATE_FAILED", "queue already exists"),
+		failureTestEvent(child, "child", "Bucket", "", "AWS::S3::Bucket", "CREATE_FAILED", "Resource creation cancelled"),
+		failureTestEvent(child, "child", "child", "child", "AWS::CloudFormation::Stack", "UPDATE_ROLLBACK_IN_PROGRESS", "The following resource(s) failed to create: [Queue]"),
+		failureTestEvent(nil, "parent", "Child", "child", "AWS::CloudFormation::Stack", "UPDATE_FAILED", "Embedded stack child was not successfully updated"),
+		failureTestEvent(nil, "parent", "Role", "role", "AWS::IAM::Role", "UPDATE_FAILED", "access denied"),
+		failureTestEvent(nil, "parent", "parent", "parent", "AWS::CloudFormation::Stack", "UPDATE_ROLLBACK_IN_PROGRESS", "The following resource(s) failed to update: [Child, Role]"),
+		failureTestEvent(nil, "parent", "Topic", "topic", "AWS::SNS::Topic", "UPDATE_FAILED", "topic is in use"),
+		failureTestEvent(nil, "parent", "parent", "parent", "AWS::CloudFormation::Stack", "UPDATE_ROLLBACK_FAILED", ""),
+	}
+
+	stack := &cloudformation.Stack{
+		StackName:   aws.String("parent-name"),
+		StackId:     aws.String("parent"),
+		StackStatus: aws.String("UPDATE_ROLLBACK_FAILED"),
+	}
+
+	summary := NewFailureSummary(stack, events)
+	assert.Equal(t, "Queue", summary.RootCause.LogicalId)
+	assert.Equal(t, []ResourceFailure{newResourceFailure(events[1]), newResourceFailure(events[5])}, summary.Failures)
+	assert.Equal(t, []ResourceFailure{newResourceFailure(events[7])}, summary.RollbackFailures)
+
+	assert.Equal(t, `Stack parent-name failed with status UPDATE_ROLLBACK_FAILED.
+
+Root cause:
+  Child/Queue (AWS::SQS::Queue) CREATE_FAILED: queue already exists
+
+First failures:
+  Child/Queue (AWS::SQS::Queue) CREATE_FAILED: queue already exists
+  Role (AWS::IAM::Role) UPDATE_FAILED: access denied
+
+Failed to roll back:
+  Topic (AWS::SNS::Topic) UPDATE_FAILED: topic is in use
+`, summary.String())
+
+	body, err := json.Marshal(summary.Failures[0])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"nestedPath": ["Child"],
+		"logicalId": "Queue",
+		"resourceType": "AWS::SQS::Queue",
+		"status": "CREATE_FAILED",
+		"reason": "queue already exists",
+		"timestamp": "2019-12-01T10:00:00Z"
+	}`, string(body))
+}
+
+func TestFailureSummaryWithoutRecordedEvents(t *testing.T) {
+	stackEvent := func(event TailStackEvent) *cloudformation.StackEvent {
+		return &event.StackEvent
+	}
+
+	stackId := "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/stack-name/abc"
+
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{
+			StackName:   aws.String("stack-name"),
+			StackId:     &stackId,
+			StackStatus: aws.String("ROLLBACK_COMPLETE"),
+		}},
+	}, nil)
+	capi.On("DescribeStackEventsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		cb := args.Get(2).(func(*cloudformation.DescribeStackEventsOutput, bool) bool)
+		cb(&cloudformation.DescribeStackEventsOutput{StackEvents: []*cloudformation.StackEvent{
+			stackEvent(failureTestEvent(nil, stackId, "stack-name", stackId, "AWS::CloudFormation::Stack", "ROLLBACK_COMPLETE", "")),
+			stackEvent(failureTestEvent(nil, stackId, "stack-name", stackId, "AWS::CloudFormation::Stack", "ROLLBACK_IN_PROGRESS", "")),
+			stackEvent(failureTestEvent(nil, stackId, "Queue", "", "AWS::SQS::Queue", "CREATE_FAILED", "queue already exists")),
+			stackEvent(failureTestEvent(nil, stackId, "stack-name", stackId, "AWS::CloudFormation::Stack", "CREATE_IN_PROGRESS", "User Initiated")),
+			stackEvent(failureTestEvent(nil, stackId, "Bucket", "", "AWS::S3::Bucket", "CREATE_FAILED", "an earlier failure")),
+		}}, true)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	summary, err := s.FailureSummary(context.Background(), stackId)
+	assert.NoError(t, err)
+	assert.Len(t, summary.Failures, 1)
+	assert.Equal(t, "Queue", summary.Failures[0].LogicalId)
+	assert.Empty(t, summary.RollbackFailures)
+	assert.Contains(t, summary.String(), "Rollback finished with status ROLLBACK_COMPLETE.")
+}
@@ -0,0 +1,71 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"sort"
+	"strings"
+)
+
+// ResourceToImport identifies an existing resource to bring under the
+// management of a stack. Identifier maps the resource type's identifier
+// properties (e.g. BucketName) to their values. For resource types with a
+// single identifier property, PhysicalId can be given instead.
+type ResourceToImport struct {
+	LogicalId  string
+	PhysicalId string
+	Identifier map[string]string
+}
+
+// resourcesToImport looks up the type and identifier properties of each
+// resource in the template and builds the list of resources for an IMPORT
+// change set.
+func (s *Stackit) resourcesToImport(ctx context.Context, templateBody, templateURL *string, resources []ResourceToImport) ([]*cloudformation.ResourceToImport, error) {
+	summary, err := s.api.GetTemplateSummaryWithContext(ctx, &cloudformation.GetTemplateSummaryInput{
+		TemplateBody: templateBody,
+		TemplateURL:  templateURL,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting template summary")
+	}
+
+	types := map[string]string{}
+	identifiers := map[string][]string{}
+	for _, ris := range summary.ResourceIdentifierSummaries {
+		for _, logicalId := range ris.LogicalResourceIds {
+			types[*logicalId] = *ris.ResourceType
+			identifiers[*logicalId] = aws.StringValueSlice(ris.ResourceIdentifiers)
+		}
+	}
+
+	imports := []*cloudformation.ResourceToImport{}
+	for _, resource := range resources {
+		resourceType, ok := types[resource.LogicalId]
+		if !ok {
+			return nil, errors.Errorf("resource %s is not in the template or can't be imported", resource.LogicalId)
+		}
+
+		identifier := resource.Identifier
+		if identifier == nil {
+			props := identifiers[resource.LogicalId]
+			if len(props) != 1 {
+				return nil, errors.Errorf("resource %s (%s) is identified by %s, which must be given individually", resource.LogicalId, resourceType, strings.Join(props, ", "))
+			}
+			identifier = map[string]string{props[0]: resource.PhysicalId}
+		}
+
+		imports = append(imports, &cloudformation.ResourceToImport{
+			LogicalResourceId:  aws.String(resource.LogicalId),
+			ResourceType:       aws.String(resourceType),
+			ResourceIdentifier: aws.StringMap(identifier),
+		})
+	}
+
+	sort.Slice(imports, func(i, j int) bool {
+		return *imports[i].LogicalResourceId < *imports[j].LogicalResourceId
+	})
+
+	return imports, nil
+}
@@ -0,0 +1,52 @@
+package stackit
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestPrepareCreatesImportChangeSet(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("GetTemplateSummaryWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.GetTemplateSummaryOutput{
+		ResourceIdentifierSummaries: []*cloudformation.ResourceIdentifierSummary{
+			{ResourceType: aws.String("AWS::S3::Bucket"), LogicalResourceIds: aws.StringSlice([]string{"Bucket"}), ResourceIdentifiers: aws.StringSlice([]string{"BucketName"})},
+			{ResourceType: aws.String("AWS::DynamoDB::Table"), LogicalResourceIds: aws.StringSlice([]string{"Table"}), ResourceIdentifiers: aws.StringSlice([]string{"TableName"})},
+		},
+	}, nil)
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CreateChangeSetInput)
+		assert.Equal(t, cloudformation.ChangeSetTypeImport, *input.ChangeSetType)
+		assert.Equal(t, []*cloudformation.ResourceToImport{
+			{LogicalResourceId: aws.String("Bucket"), ResourceType: aws.String("AWS::S3::Bucket"), ResourceIdentifier: aws.StringMap(map[string]string{"BucketName": "my-bucket"})},
+			{LogicalResourceId: aws.String("Table"), ResourceType: aws.String("AWS::DynamoDB::Table"), ResourceIdentifier: aws.StringMap(map[string]string{"TableName": "my-table"})},
+		}, input.ResourcesToImport)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{
+		StackName: "stack-name",
+		Template:  stringTemplate("Resources: {}"),
+		ResourcesToImport: []ResourceToImport{
+			{LogicalId: "Table", Identifier: map[string]string{"TableName": "my-table"}},
+			{LogicalId: "Bucket", PhysicalId: "my-bucket"},
+		},
+	}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "creating change set: done")
+}
+
+func TestImportUnknownResource(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("GetTemplateSummaryWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.GetTemplateSummaryOutput{}, nil)
+
+	s := NewStackit(capi, &mockSts{})
+	_, err := s.resourcesToImport(context.Background(), aws.String("Resources: {}"), nil, []ResourceToImport{{LogicalId: "Bucket", PhysicalId: "my-bucket"}})
+	assert.EqualError(t, err, "resource Bucket is not in the template or can't be imported")
+}
@@ -0,0 +1,203 @@
+package manifest
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Manifest describes a set of related stacks that are deployed together.
+type Manifest struct {
+	Stacks map[string]*Stack `yaml:"stacks"`
+
+	dir string
+}
+
+// Stack is a single stack in a manifest. Parameter values can reference the
+// outputs of other stacks in the manifest with {{output:<id>.<OutputKey>}},
+// which also makes the referring stack depend on the referenced one. <id> is
+// the stack's key in the manifest, not its stack name. References to stacks
+// outside the manifest use the {{stack:<stack-name>.<OutputKey>}} syntax
+// accepted by up, which is resolved when the stack is deployed and doesn't
+// affect the order stacks are deployed in.
+type Stack struct {
+	StackName   string            `yaml:"stack-name"`
+	Template    string            `yaml:"template"`
+	Parameters  map[string]string `yaml:"parameters"`
+	Tags        map[string]string `yaml:"tags"`
+	ServiceRole string            `yaml:"service-role"`
+	DependsOn   []string          `yaml:"depends-on"`
+}
+
+var outputReference = regexp.MustCompile(`\{\{output:([^.}]+)\.([^}]+)\}\}`)
+
+func Read(path string) (*Manifest, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading manifest")
+	}
+
+	m, err := Parse(body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing manifest %s", path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "determining absolute path of '%s'", path)
+	}
+
+	m.dir = filepath.Dir(abs)
+	return m, nil
+}
+
+func Parse(body []byte) (*Manifest, error) {
+	m := &Manifest{}
+	err := yaml.Unmarshal(body, m)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling yaml")
+	}
+
+	if len(m.Stacks) == 0 {
+		return nil, errors.New("no stacks defined in manifest")
+	}
+
+	for id, stack := range m.Stacks {
+		if stack == nil {
+			return nil, errors.Errorf("stack `%s` has no definition", id)
+		}
+
+		if stack.StackName == "" {
+			stack.StackName = id
+		}
+	}
+
+	return m, nil
+}
+
+// TemplatePath returns the path of the stack's template, relative to the
+// manifest file if the template path is not absolute.
+func (m *Manifest) TemplatePath(id string) string {
+	path := m.Stacks[id].Template
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(m.dir, path)
+}
+
+// Dependencies returns the sorted IDs of stacks that the given stack depends
+// on, both explicitly and via output references in its parameters.
+func (m *Manifest) Dependencies(id string) []string {
+	stack := m.Stacks[id]
+	seen := map[string]bool{}
+
+	for _, dep := range stack.DependsOn {
+		seen[dep] = true
+	}
+
+	for _, value := range stack.Parameters {
+		for _, match := range outputReference.FindAllStringSubmatch(value, -1) {
+			seen[match[1]] = true
+		}
+	}
+
+	deps := []string{}
+	for dep := range seen {
+		deps = append(deps, dep)
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// Order returns stack IDs in an order such that every stack comes after the
+// stacks it depends on.
+func (m *Manifest) Order() ([]string, error) {
+	ids := []string{}
+	for id := range m.Stacks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		for _, dep := range m.Dependencies(id) {
+			if _, ok := m.Stacks[dep]; !ok {
+				return nil, errors.Errorf("stack `%s` depends on unknown stack `%s`", id, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	order := []string{}
+
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visited:
+			return nil
+		case visiting:
+			return errors.Errorf("dependency cycle between stacks: %v", append(path, id))
+		}
+
+		state[id] = visiting
+		for _, dep := range m.Dependencies(id) {
+			if err := visit(dep, append(path, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		order = append(order, id)
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// ResolveParameters substitutes output references in the stack's parameter
+// values with the outputs of previously deployed stacks, keyed by stack ID.
+func (m *Manifest) ResolveParameters(id string, outputs map[string]map[string]string) (map[string]string, error) {
+	resolved := map[string]string{}
+
+	for name, value := range m.Stacks[id].Parameters {
+		var err error
+		resolved[name] = outputReference.ReplaceAllStringFunc(value, func(ref string) string {
+			match := outputReference.FindStringSubmatch(ref)
+			refStack, refOutput := match[1], match[2]
+
+			stackOutputs, ok := outputs[refStack]
+			if !ok {
+				err = errors.Errorf("parameter %s of stack `%s` references stack `%s`, which has not been deployed", name, id, refStack)
+				return ref
+			}
+
+			value, ok := stackOutputs[refOutput]
+			if !ok {
+				err = errors.Errorf("parameter %s of stack `%s` references output %s, which stack `%s` does not have", name, id, refOutput, refStack)
+				return ref
+			}
+
+			return value
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
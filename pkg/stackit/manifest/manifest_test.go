@@ -0,0 +1,93 @@
+package manifest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const body = `
+stacks:
+  app:
+    stack-name: app-prod
+    template: app.yml
+    parameters:
+      VpcId: "{{output:network.VpcId}}"
+      Endpoint: "https://{{output:database.Host}}:5432"
+      Count: 3
+  database:
+    template: database.yml
+    depends-on: [network]
+  network:
+    template: network.yml
+    tags:
+      env: prod
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(body))
+	assert.NoError(t, err)
+	assert.Equal(t, "app-prod", m.Stacks["app"].StackName)
+	assert.Equal(t, "network", m.Stacks["network"].StackName)
+	assert.Equal(t, "3", m.Stacks["app"].Parameters["Count"])
+	assert.Equal(t, map[string]string{"env": "prod"}, m.Stacks["network"].Tags)
+}
+
+func TestOrder(t *testing.T) {
+	m, err := Parse([]byte(body))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"database", "network"}, m.Dependencies("app"))
+
+	order, err := m.Order()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"network", "database", "app"}, order)
+}
+
+func TestOrderDetectsCycles(t *testing.T) {
+	m, err := Parse([]byte(`
+stacks:
+  a:
+    depends-on: [b]
+  b:
+    parameters:
+      Foo: "{{output:a.Bar}}"
+`))
+	assert.NoError(t, err)
+
+	_, err = m.Order()
+	assert.EqualError(t, err, "dependency cycle between stacks: [a b a]")
+}
+
+func TestOrderRejectsUnknownStacks(t *testing.T) {
+	m, err := Parse([]byte(`
+stacks:
+  a:
+    depends-on: [nope]
+`))
+	assert.NoError(t, err)
+
+	_, err = m.Order()
+	assert.EqualError(t, err, "stack `a` depends on unknown stack `nope`")
+}
+
+func TestResolveParameters(t *testing.T) {
+	m, err := Parse([]byte(body))
+	assert.NoError(t, err)
+
+	outputs := map[string]map[string]string{
+		"network":  {"VpcId": "vpc-123"},
+		"database": {"Host": "db.example.com"},
+	}
+
+	params, err := m.ResolveParameters("app", outputs)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"VpcId":    "vpc-123",
+		"Endpoint": "https://db.example.com:5432",
+		"Count":    "3",
+	}, params)
+
+	delete(outputs["database"], "Host")
+	_, err = m.ResolveParameters("app", outputs)
+	assert.EqualError(t, err, "parameter Endpoint of stack `app` references output Host, which stack `database` does not have")
+}
@@ -102,7 +102,7 @@ func (m *mockCfn) DescribeStackEventsWithContext(ctx context.Context, input *clo
 }
 
 func (m *mockCfn) DescribeStackEventsPagesWithContext(ctx context.Context, input *cloudformation.DescribeStackEventsInput, cb func(*cloudformation.DescribeStackEventsOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -125,7 +125,7 @@ func (m *mockCfn) DescribeStackResourceDriftsWithContext(ctx context.Context, in
 }
 
 func (m *mockCfn) DescribeStackResourceDriftsPagesWithContext(ctx context.Context, input *cloudformation.DescribeStackResourceDriftsInput, cb func(*cloudformation.DescribeStackResourceDriftsOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -154,7 +154,7 @@ func (m *mockCfn) DescribeStacksWithContext(ctx context.Context, input *cloudfor
 }
 
 func (m *mockCfn) DescribeStacksPagesWithContext(ctx context.Context, input *cloudformation.DescribeStacksInput, cb func(*cloudformation.DescribeStacksOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -231,7 +231,7 @@ func (m *mockCfn) ListExportsWithContext(ctx context.Context, input *cloudformat
 }
 
 func (m *mockCfn) ListExportsPagesWithContext(ctx context.Context, input *cloudformation.ListExportsInput, cb func(*cloudformation.ListExportsOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -242,7 +242,7 @@ func (m *mockCfn) ListImportsWithContext(ctx context.Context, input *cloudformat
 }
 
 func (m *mockCfn) ListImportsPagesWithContext(ctx context.Context, input *cloudformation.ListImportsInput, cb func(*cloudformation.ListImportsOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -259,7 +259,7 @@ func (m *mockCfn) ListStackResourcesWithContext(ctx context.Context, input *clou
 }
 
 func (m *mockCfn) ListStackResourcesPagesWithContext(ctx context.Context, input *cloudformation.ListStackResourcesInput, cb func(*cloudformation.ListStackResourcesOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -288,7 +288,7 @@ func (m *mockCfn) ListStacksWithContext(ctx context.Context, input *cloudformati
 }
 
 func (m *mockCfn) ListStacksPagesWithContext(ctx context.Context, input *cloudformation.ListStacksInput, cb func(*cloudformation.ListStacksOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -299,7 +299,7 @@ func (m *mockCfn) ListTypeRegistrationsWithContext(ctx context.Context, input *c
 }
 
 func (m *mockCfn) ListTypeRegistrationsPagesWithContext(ctx context.Context, input *cloudformation.ListTypeRegistrationsInput, cb func(*cloudformation.ListTypeRegistrationsOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -310,7 +310,7 @@ func (m *mockCfn) ListTypeVersionsWithContext(ctx context.Context, input *cloudf
 }
 
 func (m *mockCfn) ListTypeVersionsPagesWithContext(ctx context.Context, input *cloudformation.ListTypeVersionsInput, cb func(*cloudformation.ListTypeVersionsOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
@@ -321,7 +321,7 @@ func (m *mockCfn) ListTypesWithContext(ctx context.Context, input *cloudformatio
 }
 
 func (m *mockCfn) ListTypesPagesWithContext(ctx context.Context, input *cloudformation.ListTypesInput, cb func(*cloudformation.ListTypesOutput, bool) bool, opts ...request.Option) error {
-	f := m.Called(ctx, input, opts)
+	f := m.Called(ctx, input, cb, opts)
 	return f.Error(0)
 }
 
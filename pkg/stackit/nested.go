@@ -0,0 +1,146 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/awsctx/service/cloudformationctx"
+	"strings"
+	"sync"
+	"time"
+)
+
+const nestedStackResourceType = "AWS::CloudFormation::Stack"
+
+// nestedStack is a nested stack being tailed by PollStackEvents.
+type nestedStack struct {
+	stackId    string
+	path       []string
+	since      time.Time
+	mostRecent *time.Time
+	done       bool
+}
+
+// nestedStacks are keyed by stack ID.
+type nestedStacks map[string]*nestedStack
+
+// discover starts tailing any nested stacks that events show are being
+// operated on. Nested stacks that have finished are tailed again if their
+// parent starts another operation on them, e.g. during a rollback.
+func (ns nestedStacks) discover(events []TailStackEvent) {
+	for _, event := range events {
+		if aws.StringValue(event.ResourceType) != nestedStackResourceType {
+			continue
+		}
+
+		physicalId := aws.StringValue(event.PhysicalResourceId)
+		if !strings.HasPrefix(physicalId, "arn:") || physicalId == aws.StringValue(event.StackId) {
+			continue
+		}
+
+		if existing, ok := ns[physicalId]; ok {
+			if strings.HasSuffix(*event.ResourceStatus, "_IN_PROGRESS") {
+				existing.done = false
+			}
+			continue
+		}
+
+		path := append(append([]string{}, event.NestedPath...), *event.LogicalResourceId)
+		ns[physicalId] = &nestedStack{
+			stackId: physicalId,
+			path:    path,
+			since:   *event.Timestamp,
+		}
+	}
+}
+
+// poll concurrently fetches new events from every nested stack that hasn't
+// finished. A nested stack whose events couldn't be fetched is polled again
+// from where it left off next time, as errors polling one nested stack
+// shouldn't stop the rest of the operation from being tailed.
+func (ns nestedStacks) poll(ctx context.Context, api cloudformationctx.CloudFormation) []TailStackEvent {
+	active := []*nestedStack{}
+	for _, stack := range ns {
+		if !stack.done {
+			active = append(active, stack)
+		}
+	}
+
+	results := make([][]TailStackEvent, len(active))
+
+	wg := sync.WaitGroup{}
+	for idx, stack := range active {
+		wg.Add(1)
+		go func(idx int, stack *nestedStack) {
+			defer wg.Done()
+			results[idx], _ = stack.poll(ctx, api)
+		}(idx, stack)
+	}
+	wg.Wait()
+
+	events := []TailStackEvent{}
+	for _, result := range results {
+		events = append(events, result...)
+	}
+
+	return events
+}
+
+func (n *nestedStack) poll(ctx context.Context, api cloudformationctx.CloudFormation) ([]TailStackEvent, error) {
+	events, err := eventsWhile(ctx, api, n.stackId, func(event *cloudformation.StackEvent) bool {
+		if n.mostRecent == nil {
+			return !event.Timestamp.Before(n.since)
+		}
+		return event.Timestamp.After(*n.mostRecent)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	n.mostRecent = events[0].Timestamp
+
+	tailEvents := []TailStackEvent{}
+	for ev_i := len(events) - 1; ev_i >= 0; ev_i-- {
+		event := events[ev_i]
+		if aws.StringValue(event.PhysicalResourceId) == n.stackId {
+			n.done = IsTerminalStatus(*event.ResourceStatus)
+		}
+		tailEvents = append(tailEvents, TailStackEvent{StackEvent: *event, NestedPath: n.path})
+	}
+
+	return tailEvents, nil
+}
+
+// RootCause returns the event that most likely caused a stack operation to
+// fail: the first failure in the most deeply nested stack. Resources whose
+// operations were only cancelled because of another failure are skipped, as
+// are the stack-level failures of the stacks themselves. It returns nil if
+// none of the events are failures.
+func RootCause(events []TailStackEvent) *TailStackEvent {
+	var cause *TailStackEvent
+
+	for idx := range events {
+		event := events[idx]
+		if !isBadStatus(aws.StringValue(event.ResourceStatus)) {
+			continue
+		}
+
+		if aws.StringValue(event.PhysicalResourceId) == aws.StringValue(event.StackId) {
+			continue
+		}
+
+		if isCancellation(event) {
+			continue
+		}
+
+		if cause == nil || len(event.NestedPath) > len(cause.NestedPath) {
+			cause = &event
+		}
+	}
+
+	return cause
+}
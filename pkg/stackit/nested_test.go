@@ -0,0 +1,126 @@
+package stackit
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestNestedStacks(t *testing.T) {
+	parentId := "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/parent/abc"
+	childId := "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/parent-Child-XYZ/def"
+	start := time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	event := func(stackId, logicalId, physicalId, typ, status string, offset time.Duration) *cloudformation.StackEvent {
+		return &cloudformation.StackEvent{
+			StackId:            aws.String(stackId),
+			LogicalResourceId:  aws.String(logicalId),
+			PhysicalResourceId: aws.String(physicalId),
+			ResourceType:       aws.String(typ),
+			ResourceStatus:     aws.String(status),
+			Timestamp:          aws.Time(start.Add(offset)),
+		}
+	}
+
+	nested := nestedStacks{}
+	nested.discover([]TailStackEvent{
+		{StackEvent: *event(parentId, "parent", parentId, nestedStackResourceType, "UPDATE_IN_PROGRESS", 0)},
+		{StackEvent: *event(parentId, "Child", "", nestedStackResourceType, "UPDATE_IN_PROGRESS", time.Second)},
+		{StackEvent: *event(parentId, "Child", childId, nestedStackResourceType, "UPDATE_IN_PROGRESS", 2*time.Second)},
+	})
+	assert.Len(t, nested, 1)
+	assert.Equal(t, []string{"Child"}, nested[childId].path)
+
+	capi := &mockCfn{}
+	capi.On("DescribeStackEventsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.DescribeStackEventsInput)
+		assert.Equal(t, childId, *input.StackName)
+
+		cb := args.Get(2).(func(*cloudformation.DescribeStackEventsOutput, bool) bool)
+		cb(&cloudformation.DescribeStackEventsOutput{StackEvents: []*cloudformation.StackEvent{
+			event(childId, "parent-Child-XYZ", childId, nestedStackResourceType, "UPDATE_COMPLETE", 5*time.Second),
+			event(childId, "Queue", "queue-url", "AWS::SQS::Queue", "UPDATE_COMPLETE", 4*time.Second),
+			event(childId, "parent-Child-XYZ", childId, nestedStackResourceType, "UPDATE_IN_PROGRESS", 3*time.Second),
+			event(childId, "parent-Child-XYZ", childId, nestedStackResourceType, "UPDATE_COMPLETE", -time.Hour),
+		}}, true)
+	})
+
+	events := nested.poll(context.Background(), capi)
+	assert.Len(t, events, 3)
+	assert.Equal(t, "Queue", *events[1].LogicalResourceId)
+	assert.Equal(t, []string{"Child"}, events[1].NestedPath)
+	assert.True(t, nested[childId].done)
+
+	// finished nested stacks aren't polled until their parent touches them again
+	events = nested.poll(context.Background(), capi)
+	assert.Empty(t, events)
+	capi.AssertNumberOfCalls(t, "DescribeStackEventsPagesWithContext", 1)
+
+	nested.discover([]TailStackEvent{
+		{StackEvent: *event(parentId, "Child", childId, nestedStackResourceType, "UPDATE_IN_PROGRESS", 10*time.Second)},
+	})
+	assert.False(t, nested[childId].done)
+}
+
+func TestNestedStacksRetryFailedPolls(t *testing.T) {
+	childId := "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/parent-Child-XYZ/def"
+	start := time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)
+	nested := nestedStacks{childId: {stackId: childId, path: []string{"Child"}, since: start}}
+
+	capi := &mockCfn{}
+	capi.On("DescribeStackEventsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("InternalFailure")).Once()
+	capi.On("DescribeStackEventsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		cb := args.Get(2).(func(*cloudformation.DescribeStackEventsOutput, bool) bool)
+		cb(&cloudformation.DescribeStackEventsOutput{StackEvents: []*cloudformation.StackEvent{{
+			StackId:            aws.String(childId),
+			LogicalResourceId:  aws.String("Queue"),
+			PhysicalResourceId: aws.String("queue-url"),
+			ResourceStatus:     aws.String("UPDATE_COMPLETE"),
+			Timestamp:          aws.Time(start.Add(time.Second)),
+		}}}, true)
+	})
+
+	assert.Empty(t, nested.poll(context.Background(), capi))
+	assert.Nil(t, nested[childId].mostRecent)
+	assert.False(t, nested[childId].done)
+
+	events := nested.poll(context.Background(), capi)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "Queue", *events[0].LogicalResourceId)
+}
+
+func TestRootCause(t *testing.T) {
+	event := func(path []string, stackId, logicalId, physicalId, status, reason string) TailStackEvent {
+		return TailStackEvent{
+			StackEvent: cloudformation.StackEvent{
+				StackId:              aws.String(stackId),
+				LogicalResourceId:    aws.String(logicalId),
+				PhysicalResourceId:   aws.String(physicalId),
+				ResourceStatus:       aws.String(status),
+				ResourceStatusReason: aws.String(reason),
+			},
+			NestedPath: path,
+		}
+	}
+
+	events := []TailStackEvent{
+		event(nil, "parent", "Topic", "", "CREATE_FAILED", "Resource creation cancelled"),
+		event([]string{"Child"}, "child", "Bucket", "", "CREATE_FAILED", "Resource creation cancelled"),
+		event([]string{"Child"}, "child", "Queue", "", "CREATE_FAILED", "queue already exists"),
+		event([]string{"Child"}, "child", "Role", "", "CREATE_FAILED", "access denied"),
+		event([]string{"Child"}, "child", "child", "child", "UPDATE_ROLLBACK_IN_PROGRESS", "The following resource(s) failed to create: [Queue, Role]"),
+		event(nil, "parent", "Child", "child", "CREATE_FAILED", "Embedded stack child was not successfully created"),
+	}
+
+	cause := RootCause(events)
+	assert.Equal(t, "Queue", *cause.LogicalResourceId)
+	assert.Equal(t, "queue already exists", *cause.ResourceStatusReason)
+
+	assert.Nil(t, RootCause(events[:2]))
+	assert.Equal(t, "Child", *RootCause(events[5:]).LogicalResourceId)
+}
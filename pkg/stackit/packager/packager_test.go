@@ -32,3 +32,24 @@ func TestMd5path(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "6f5902ac237024bdd0c176cb93063dc4", sum)
 }
+
+func TestTemplateURL(t *testing.T) {
+	for _, tc := range []struct {
+		region   string
+		up       *UploadedObject
+		expected string
+	}{
+		{"ap-southeast-2", &UploadedObject{Bucket: "bucket", Key: "stack/template.yml/abc"}, "https://bucket.s3.ap-southeast-2.amazonaws.com/stack/template.yml/abc"},
+		{"us-west-2", &UploadedObject{Bucket: "bucket", Key: "key", VersionId: "v1"}, "https://bucket.s3.us-west-2.amazonaws.com/key?versionId=v1"},
+		{"cn-north-1", &UploadedObject{Bucket: "bucket", Key: "key"}, "https://bucket.s3.cn-north-1.amazonaws.com.cn/key"},
+	} {
+		t.Run(tc.region, func(t *testing.T) {
+			sess := session.Must(session.NewSession(aws.NewConfig().WithRegion(tc.region)))
+			p := New(s3.New(sess), sts.New(sess), tc.region)
+
+			u, err := p.templateURL(tc.up)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, u)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package packager
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// UploadTemplate uploads a template body to the packager's bucket and
+// returns a URL suitable for passing to CloudFormation as a TemplateURL.
+// Like packaged artifacts, the key includes a hash of the content so that
+// unchanged templates aren't uploaded again.
+func (p *Packager) UploadTemplate(ctx context.Context, prefix, body string) (string, error) {
+	sum := md5.Sum([]byte(body))
+	hash := hex.EncodeToString(sum[:])
+	key := strings.TrimPrefix(fmt.Sprintf("%s/template.yml/%s", prefix, hash), "/")
+
+	up, err := p.upload(ctx, key, "template", strings.NewReader(body))
+	if err != nil {
+		return "", errors.Wrap(err, "uploading template to s3")
+	}
+
+	return p.templateURL(up)
+}
+
+// templateURL returns the URL of an uploaded object as resolved by the S3
+// client, so that it is correct for the client's partition and endpoint
+// rather than assuming amazonaws.com.
+func (p *Packager) templateURL(up *UploadedObject) (string, error) {
+	input := &s3.GetObjectInput{Bucket: &up.Bucket, Key: &up.Key}
+	if up.VersionId != "" {
+		input.VersionId = &up.VersionId
+	}
+
+	req, _ := p.s3.GetObjectRequest(input)
+	if err := req.Build(); err != nil {
+		return "", errors.Wrap(err, "resolving template url")
+	}
+
+	return req.HTTPRequest.URL.String(), nil
+}
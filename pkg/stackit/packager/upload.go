@@ -2,9 +2,11 @@ package packager
 
 import (
 	"context"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/pkg/errors"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -17,6 +19,23 @@ type UploadedObject struct {
 }
 
 func (p *Packager) Upload(ctx context.Context, key, path string) (*UploadedObject, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "determining absolute path of '%s'", path)
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening file '%s'", absPath)
+	}
+	defer file.Close()
+
+	return p.upload(ctx, key, absPath, file)
+}
+
+// upload uploads body to key unless an object already exists there. name is
+// only used in error messages.
+func (p *Packager) upload(ctx context.Context, key, name string, body io.Reader) (*UploadedObject, error) {
 	uploader := s3manager.NewUploaderWithClient(p.s3)
 
 	bucket, err := p.s3BucketName()
@@ -42,29 +61,19 @@ func (p *Packager) Upload(ctx context.Context, key, path string) (*UploadedObjec
 		}, nil
 	}
 
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return nil, errors.Wrapf(err, "determining absolute path of '%s'", path)
-	}
-
-	file, err := os.Open(absPath)
-	if err != nil {
-		return nil, errors.Wrapf(err, "opening file '%s'", absPath)
-	}
-
 	resp, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket: &bucket,
 		Key:    &key,
-		Body:   file,
+		Body:   body,
 	})
 	if err != nil {
-		return nil, errors.Wrapf(err, "uploading %s to to s3://%s/%s", absPath, bucket, key)
+		return nil, errors.Wrapf(err, "uploading %s to to s3://%s/%s", name, bucket, key)
 	}
 
 	return &UploadedObject{
 		Bucket:    bucket,
 		Key:       key,
-		VersionId: *resp.VersionID,
+		VersionId: aws.StringValue(resp.VersionID),
 	}, nil
 }
 
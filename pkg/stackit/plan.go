@@ -0,0 +1,129 @@
+package stackit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Plan is a persistable record of a prepared change set, so that it can be
+// reviewed and later executed by a separate invocation of stackit.
+type Plan struct {
+	StackName    string
+	StackId      string
+	ChangeSetId  string
+	Parameters   []*cloudformation.Parameter
+	Changes      []*cloudformation.Change
+	TemplateHash string
+
+	StackPolicyBody             string
+	StackPolicyDuringUpdateBody string
+
+	// StackLastUpdatedTime is the stack's last update (or creation) time at
+	// the time the plan was made.
+	StackLastUpdatedTime *time.Time
+}
+
+// HasChanges is false for plans made when the stack was already up to date.
+func (p *Plan) HasChanges() bool {
+	return p.ChangeSetId != ""
+}
+
+func (s *Stackit) NewPlan(ctx context.Context, input StackitUpInput, prepared *PrepareOutput) (*Plan, error) {
+	plan := &Plan{
+		StackName:                   input.StackName,
+		StackPolicyBody:             input.StackPolicyBody,
+		StackPolicyDuringUpdateBody: input.StackPolicyDuringUpdateBody,
+	}
+	if prepared == nil {
+		return plan, nil
+	}
+
+	stack, err := s.Describe(ctx, *prepared.Output.StackId)
+	if err != nil {
+		return nil, errors.Wrap(err, "describing stack")
+	}
+
+	plan.StackId = *prepared.Output.StackId
+	plan.ChangeSetId = *prepared.Output.Id
+	plan.Parameters = prepared.Input.Parameters
+	plan.Changes = prepared.Changes
+	plan.TemplateHash = templateHash(prepared.TemplateBody)
+	plan.StackLastUpdatedTime = stackLastUpdatedTime(stack)
+	return plan, nil
+}
+
+// VerifyPlan returns an error if the plan's change set can no longer be
+// executed as it was reviewed: the change set is gone or not executable, its
+// processed template differs, or the stack has been updated since planning.
+func (s *Stackit) VerifyPlan(ctx context.Context, plan *Plan) error {
+	cs, err := s.api.DescribeChangeSetWithContext(ctx, &cloudformation.DescribeChangeSetInput{
+		ChangeSetName: &plan.ChangeSetId,
+		StackName:     &plan.StackId,
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == cloudformation.ErrCodeChangeSetNotFoundException {
+			return errors.Errorf("change set %s no longer exists", plan.ChangeSetId)
+		}
+		return errors.Wrap(err, "describing change set")
+	}
+
+	if status := aws.StringValue(cs.Status); status != cloudformation.ChangeSetStatusCreateComplete {
+		return errors.Errorf("change set %s has status %s, expected %s", plan.ChangeSetId, status, cloudformation.ChangeSetStatusCreateComplete)
+	}
+
+	if status := aws.StringValue(cs.ExecutionStatus); status != cloudformation.ExecutionStatusAvailable {
+		return errors.Errorf("change set %s has execution status %s", plan.ChangeSetId, status)
+	}
+
+	getResp, err := s.api.GetTemplateWithContext(ctx, &cloudformation.GetTemplateInput{
+		ChangeSetName: &plan.ChangeSetId,
+		StackName:     &plan.StackId,
+		TemplateStage: aws.String(cloudformation.TemplateStageProcessed),
+	})
+	if err != nil {
+		return errors.Wrap(err, "getting processed template body")
+	}
+
+	if hash := templateHash(aws.StringValue(getResp.TemplateBody)); hash != plan.TemplateHash {
+		return errors.Errorf("processed template hash %s doesn't match planned hash %s", hash, plan.TemplateHash)
+	}
+
+	stack, err := s.Describe(ctx, plan.StackId)
+	if err != nil {
+		return errors.Wrap(err, "describing stack")
+	}
+
+	if stack == nil {
+		return errors.Errorf("stack %s no longer exists", plan.StackName)
+	}
+
+	planned, current := plan.StackLastUpdatedTime, stackLastUpdatedTime(stack)
+	if planned == nil || current == nil || !planned.Equal(*current) {
+		return errors.Errorf("stack %s has been modified since the plan was made", plan.StackName)
+	}
+
+	return nil
+}
+
+func stackLastUpdatedTime(stack *cloudformation.Stack) *time.Time {
+	if stack == nil {
+		return nil
+	}
+
+	if stack.LastUpdatedTime != nil {
+		return stack.LastUpdatedTime
+	}
+
+	return stack.CreationTime
+}
+
+func templateHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
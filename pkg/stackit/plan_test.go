@@ -0,0 +1,74 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestVerifyPlan(t *testing.T) {
+	planned := time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)
+	body := "Resources: {}"
+
+	newPlan := func() *Plan {
+		return &Plan{
+			StackName:            "stack-name",
+			StackId:              "stack-id",
+			ChangeSetId:          "cs-id",
+			TemplateHash:         templateHash(body),
+			StackLastUpdatedTime: &planned,
+		}
+	}
+
+	newApi := func(csStatus string, lastUpdated time.Time) *mockCfn {
+		capi := &mockCfn{}
+		capi.On("DescribeChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeChangeSetOutput{
+			Status:          aws.String(csStatus),
+			ExecutionStatus: aws.String(cloudformation.ExecutionStatusAvailable),
+		}, nil)
+		capi.On("GetTemplateWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.GetTemplateOutput{
+			TemplateBody: aws.String(body),
+		}, nil)
+		capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeStacksOutput{
+			Stacks: []*cloudformation.Stack{{
+				StackId:         aws.String("stack-id"),
+				LastUpdatedTime: &lastUpdated,
+			}},
+		}, nil)
+		return capi
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		s := NewStackit(newApi(cloudformation.ChangeSetStatusCreateComplete, planned), &mockSts{})
+		assert.NoError(t, s.VerifyPlan(context.Background(), newPlan()))
+	})
+
+	t.Run("stack updated since plan", func(t *testing.T) {
+		s := NewStackit(newApi(cloudformation.ChangeSetStatusCreateComplete, planned.Add(time.Minute)), &mockSts{})
+		assert.EqualError(t, s.VerifyPlan(context.Background(), newPlan()), "stack stack-name has been modified since the plan was made")
+	})
+
+	t.Run("change set not ready", func(t *testing.T) {
+		s := NewStackit(newApi(cloudformation.ChangeSetStatusFailed, planned), &mockSts{})
+		assert.EqualError(t, s.VerifyPlan(context.Background(), newPlan()), "change set cs-id has status FAILED, expected CREATE_COMPLETE")
+	})
+
+	t.Run("change set deleted", func(t *testing.T) {
+		capi := &mockCfn{}
+		capi.On("DescribeChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New(cloudformation.ErrCodeChangeSetNotFoundException, "", nil))
+		s := NewStackit(capi, &mockSts{})
+		assert.EqualError(t, s.VerifyPlan(context.Background(), newPlan()), "change set cs-id no longer exists")
+	})
+
+	t.Run("template changed", func(t *testing.T) {
+		p := newPlan()
+		p.TemplateHash = templateHash("something else")
+		s := NewStackit(newApi(cloudformation.ChangeSetStatusCreateComplete, planned), &mockSts{})
+		assert.Contains(t, s.VerifyPlan(context.Background(), p).Error(), "doesn't match planned hash")
+	})
+}
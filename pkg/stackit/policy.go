@@ -0,0 +1,184 @@
+package stackit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// allowAllStackPolicy is equivalent to a stack having no stack policy, which
+// can't otherwise be restored once a policy has been set.
+const allowAllStackPolicy = `{"Statement":[{"Effect":"Allow","Action":"Update:*","Principal":"*","Resource":"*"}]}`
+
+// restoreStackPolicyTimeout limits how long restoring a stack's policy after
+// an update may take, as it can't be limited by the update's own context.
+const restoreStackPolicyTimeout = time.Minute
+
+var stackPolicyActions = []string{"Update:*", "Update:Modify", "Update:Replace", "Update:Delete"}
+
+type stackPolicyStatement struct {
+	Effect      string
+	Action      interface{}
+	NotAction   interface{}
+	Principal   interface{}
+	Resource    interface{}
+	NotResource interface{}
+	Condition   interface{}
+}
+
+// ValidateStackPolicy checks that body is a well-formed stack policy document
+// without making any API calls.
+func ValidateStackPolicy(body string) error {
+	doc := struct{ Statement []stackPolicyStatement }{}
+	err := json.Unmarshal([]byte(body), &doc)
+	if err != nil {
+		return errors.Wrap(err, "parsing stack policy")
+	}
+
+	if len(doc.Statement) == 0 {
+		return errors.New("stack policy must contain at least one statement")
+	}
+
+	for idx, stmt := range doc.Statement {
+		if stmt.Effect != "Allow" && stmt.Effect != "Deny" {
+			return errors.Errorf("stack policy statement %d: Effect must be Allow or Deny", idx)
+		}
+
+		if (stmt.Action == nil) == (stmt.NotAction == nil) {
+			return errors.Errorf("stack policy statement %d: exactly one of Action or NotAction is required", idx)
+		}
+
+		actions, ok := stringOrSlice(stmt.Action)
+		if stmt.NotAction != nil {
+			actions, ok = stringOrSlice(stmt.NotAction)
+		}
+		if !ok {
+			return errors.Errorf("stack policy statement %d: actions must be a string or list of strings", idx)
+		}
+
+		for _, action := range actions {
+			if !stringInSlice(stackPolicyActions, action) {
+				return errors.Errorf("stack policy statement %d: unknown action %s (expected one of %s)", idx, action, strings.Join(stackPolicyActions, ", "))
+			}
+		}
+
+		if stmt.Principal == nil {
+			return errors.Errorf("stack policy statement %d: Principal is required", idx)
+		}
+
+		if (stmt.Resource == nil) == (stmt.NotResource == nil) {
+			return errors.Errorf("stack policy statement %d: exactly one of Resource or NotResource is required", idx)
+		}
+	}
+
+	return nil
+}
+
+func stringOrSlice(v interface{}) ([]string, bool) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		strs := []string{}
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, str)
+		}
+		return strs, true
+	default:
+		return nil, false
+	}
+}
+
+func (s *Stackit) SetStackPolicy(ctx context.Context, stackId, body string) error {
+	_, err := s.api.SetStackPolicyWithContext(ctx, &cloudformation.SetStackPolicyInput{
+		StackName:       &stackId,
+		StackPolicyBody: &body,
+	})
+	return errors.Wrap(err, "setting stack policy")
+}
+
+// OverrideStackPolicy sets a temporary stack policy for the duration of an
+// update. The returned function restores the stack's previous policy.
+func (s *Stackit) OverrideStackPolicy(ctx context.Context, stackId, body string) (func(ctx context.Context) error, error) {
+	resp, err := s.api.GetStackPolicyWithContext(ctx, &cloudformation.GetStackPolicyInput{StackName: &stackId})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current stack policy")
+	}
+
+	previous := aws.StringValue(resp.StackPolicyBody)
+	if previous == "" {
+		previous = allowAllStackPolicy
+	}
+
+	err = s.SetStackPolicy(ctx, stackId, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "overriding stack policy")
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Wrap(s.SetStackPolicy(ctx, stackId, previous), "restoring stack policy")
+	}, nil
+}
+
+// ExecuteWithStackPolicy executes a change set like Execute. If duringUpdate
+// is non-empty and the stack already exists, it is used as a temporary stack
+// policy while the change set executes, and the previous policy is restored
+// afterwards even if ctx has been cancelled (e.g. because execution timed
+// out). If policy is non-empty, it is set once the stack has been
+// successfully created or updated.
+func (s *Stackit) ExecuteWithStackPolicy(ctx context.Context, stackId, changeSetId, policy, duringUpdate string, events chan<- TailStackEvent) error {
+	if duringUpdate != "" {
+		stack, err := s.Describe(ctx, stackId)
+		if err != nil {
+			return err
+		}
+
+		if stack != nil && *stack.StackStatus != cloudformation.StackStatusReviewInProgress {
+			restore, err := s.OverrideStackPolicy(ctx, stackId, duringUpdate)
+			if err != nil {
+				return err
+			}
+
+			err = s.Execute(ctx, stackId, changeSetId, events)
+
+			restoreCtx, cancel := context.WithTimeout(context.Background(), restoreStackPolicyTimeout)
+			restoreErr := restore(restoreCtx)
+			cancel()
+			if err == nil {
+				err = restoreErr
+			}
+			if err != nil {
+				return err
+			}
+
+			return s.setStackPolicyIfSuccessful(ctx, stackId, policy)
+		}
+	}
+
+	err := s.Execute(ctx, stackId, changeSetId, events)
+	if err != nil {
+		return err
+	}
+
+	return s.setStackPolicyIfSuccessful(ctx, stackId, policy)
+}
+
+func (s *Stackit) setStackPolicyIfSuccessful(ctx context.Context, stackId, policy string) error {
+	if policy == "" {
+		return nil
+	}
+
+	if success, _ := s.IsSuccessfulState(ctx, stackId); !success {
+		return nil
+	}
+
+	return s.SetStackPolicy(ctx, stackId, policy)
+}
@@ -0,0 +1,104 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestValidateStackPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{
+			name:   "valid",
+			policy: `{"Statement":[{"Effect":"Deny","Action":["Update:Replace","Update:Delete"],"Principal":"*","Resource":"LogicalResourceId/Database"}]}`,
+		},
+		{
+			name:   "not json",
+			policy: `Statement: []`,
+			err:    "parsing stack policy: invalid character 'S' looking for beginning of value",
+		},
+		{
+			name:   "no statements",
+			policy: `{"Statement":[]}`,
+			err:    "stack policy must contain at least one statement",
+		},
+		{
+			name:   "bad effect",
+			policy: `{"Statement":[{"Effect":"Maybe","Action":"Update:*","Principal":"*","Resource":"*"}]}`,
+			err:    "stack policy statement 0: Effect must be Allow or Deny",
+		},
+		{
+			name:   "unknown action",
+			policy: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Principal":"*","Resource":"*"}]}`,
+			err:    "stack policy statement 0: unknown action s3:GetObject (expected one of Update:*, Update:Modify, Update:Replace, Update:Delete)",
+		},
+		{
+			name:   "missing resource",
+			policy: `{"Statement":[{"Effect":"Allow","NotAction":"Update:Delete","Principal":"*"}]}`,
+			err:    "stack policy statement 0: exactly one of Resource or NotResource is required",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateStackPolicy(test.policy)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestOverrideStackPolicyRestoresAllowAllWhenNoPreviousPolicy(t *testing.T) {
+	override := `{"Statement":[{"Effect":"Allow","Action":"Update:*","Principal":"*","Resource":"LogicalResourceId/Database"}]}`
+
+	var set []string
+	capi := &mockCfn{}
+	capi.On("GetStackPolicyWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.GetStackPolicyOutput{}, nil)
+	capi.On("SetStackPolicyWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.SetStackPolicyOutput{}, nil).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.SetStackPolicyInput)
+		set = append(set, *input.StackPolicyBody)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	restore, err := s.OverrideStackPolicy(context.Background(), "stack-id", override)
+	assert.NoError(t, err)
+	assert.NoError(t, restore(context.Background()))
+	assert.Equal(t, []string{override, allowAllStackPolicy}, set)
+}
+
+func TestExecuteWithStackPolicyRestoresPolicyWhenCancelled(t *testing.T) {
+	override := `{"Statement":[{"Effect":"Allow","Action":"Update:*","Principal":"*","Resource":"*"}]}`
+	previous := `{"Statement":[{"Effect":"Deny","Action":"Update:*","Principal":"*","Resource":"*"}]}`
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var set []string
+	var setErrs []error
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateComplete), nil)
+	capi.On("GetStackPolicyWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.GetStackPolicyOutput{StackPolicyBody: &previous}, nil)
+	capi.On("SetStackPolicyWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.SetStackPolicyOutput{}, nil).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.SetStackPolicyInput)
+		set = append(set, *input.StackPolicyBody)
+		setErrs = append(setErrs, args.Get(0).(context.Context).Err())
+	})
+	capi.On("ExecuteChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, context.Canceled).Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.ExecuteWithStackPolicy(ctx, "stack-id", "change-set-id", "", override, make(chan TailStackEvent))
+	assert.EqualError(t, err, "executing change set: context canceled")
+	assert.Equal(t, []string{override, previous}, set)
+	assert.Equal(t, []error{nil, nil}, setErrs)
+}
@@ -2,16 +2,25 @@ package stackit
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/glassechidna/awsctx/service/cloudformationctx"
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
+	"sort"
 	"time"
 )
 
 type TailStackEvent struct {
 	cloudformation.StackEvent
+
+	// NestedPath holds the logical IDs of the nested stack resources, from
+	// the outermost inwards, that lead to the stack the event belongs to.
+	// It is empty for events of the stack being polled.
+	NestedPath []string
 }
 
+// eventsWhile fetches the stack's events, most recent first, until include
+// returns false. It's only used to poll, so throttled calls are left to the
+// caller's Poller to retry.
 func eventsWhile(ctx context.Context, api cloudformationctx.CloudFormation, stackId string, include func(event *cloudformation.StackEvent) bool) ([]*cloudformation.StackEvent, error) {
 	var events []*cloudformation.StackEvent
 
@@ -27,62 +36,80 @@ func eventsWhile(ctx context.Context, api cloudformationctx.CloudFormation, stac
 		}
 
 		return true
-	})
+	}, retry.WithoutThrottlingRetries)
 
 	return events, err
 }
 
+// PollStackEvents passes events for the stack operation started with token to
+// callback until the stack reaches a terminal status. Stacks with rollback
+// triggers stay in progress, without new events, for the monitoring period
+// after their resources are deployed, so polling continues through it and an
+// alarm-triggered rollback is tailed like any other.
+//
+// Nested stacks are discovered from the events of their parents and tailed
+// alongside them. Their events are passed to callback in timestamp order with
+// the rest, with NestedPath set.
 func (s *Stackit) PollStackEvents(ctx context.Context, stackId, token string, callback func(event TailStackEvent)) (*TailStackEvent, error) {
 	var mostRecent *time.Time
-	tick := time.NewTicker(3 * time.Second)
+	var pollErr error
+	nested := nestedStacks{}
+	poller := s.retry.Poller(s.pollInterval)
 
 	for {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-tick.C:
-			var events []*cloudformation.StackEvent
-			var err error
-			if mostRecent == nil {
-				events, err = eventsWhile(ctx, s.api, stackId, func(event *cloudformation.StackEvent) bool {
-					return event.ClientRequestToken != nil && *event.ClientRequestToken == token
-				})
-			} else {
-				events, err = eventsWhile(ctx, s.api, stackId, func(event *cloudformation.StackEvent) bool {
-					return event.Timestamp.After(*mostRecent)
-				})
-			}
+		err := poller.Wait(ctx, pollErr)
+		if err != nil {
+			return nil, err
+		}
 
-			if err != nil {
-				if awsErr, ok := err.(awserr.Error); ok {
-					code := awsErr.Code()
-					if code != "ThrottlingException" {
-						return nil, err
-					}
-				} else {
-					return nil, err
-				}
-			}
+		var events []*cloudformation.StackEvent
+		if mostRecent == nil {
+			events, pollErr = eventsWhile(ctx, s.api, stackId, func(event *cloudformation.StackEvent) bool {
+				return event.ClientRequestToken != nil && *event.ClientRequestToken == token
+			})
+		} else {
+			events, pollErr = eventsWhile(ctx, s.api, stackId, func(event *cloudformation.StackEvent) bool {
+				return event.Timestamp.After(*mostRecent)
+			})
+		}
 
-			if len(events) == 0 {
-				continue
-			}
+		if pollErr != nil {
+			continue
+		}
 
-			mostRecent = events[0].Timestamp
+		tailEvents := []TailStackEvent{}
+		for ev_i := len(events) - 1; ev_i >= 0; ev_i-- {
+			tailEvents = append(tailEvents, TailStackEvent{StackEvent: *events[ev_i]})
+		}
+		nested.discover(tailEvents)
 
-			stack, err := s.Describe(ctx, *events[0].StackId)
-			if err != nil {
-				return nil, err
-			}
-			for ev_i := len(events) - 1; ev_i >= 0; ev_i-- {
-				event := events[ev_i]
-				tailEvent := TailStackEvent{*event}
+		nestedEvents := nested.poll(ctx, s.api)
+		nested.discover(nestedEvents)
+
+		tailEvents = append(tailEvents, nestedEvents...)
+		sort.SliceStable(tailEvents, func(i, j int) bool {
+			return tailEvents[i].Timestamp.Before(*tailEvents[j].Timestamp)
+		})
+
+		if len(events) == 0 {
+			for _, tailEvent := range tailEvents {
 				callback(tailEvent)
 			}
+			continue
+		}
 
-			if IsTerminalStatus(*stack.StackStatus) {
-				return &TailStackEvent{*events[0]}, nil
-			}
+		mostRecent = events[0].Timestamp
+
+		stack, err := s.Describe(ctx, *events[0].StackId)
+		if err != nil {
+			return nil, err
+		}
+		for _, tailEvent := range tailEvents {
+			callback(tailEvent)
+		}
+
+		if IsTerminalStatus(*stack.StackStatus) {
+			return &TailStackEvent{StackEvent: *events[0]}, nil
 		}
 	}
 }
@@ -99,7 +126,10 @@ func IsTerminalStatus(status string) bool {
 		"UPDATE_COMPLETE",
 		"UPDATE_FAILED",
 		"UPDATE_ROLLBACK_COMPLETE",
-		"UPDATE_ROLLBACK_FAILED":
+		"UPDATE_ROLLBACK_FAILED",
+		"IMPORT_COMPLETE",
+		"IMPORT_ROLLBACK_COMPLETE",
+		"IMPORT_ROLLBACK_FAILED":
 		return true
 	default:
 		return false
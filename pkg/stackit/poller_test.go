@@ -0,0 +1,71 @@
+package stackit
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPollStackEventsContinuesAfterServerError(t *testing.T) {
+	stackId := "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/stack-name/abc"
+	var eventPolls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		w.Header().Set("Content-Type", "text/xml")
+
+		switch r.Form.Get("Action") {
+		case "DescribeStackEvents":
+			if atomic.AddInt32(&eventPolls, 1) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `<ErrorResponse><Error><Type>Receiver</Type><Code>InternalFailure</Code><Message>oops</Message></Error></ErrorResponse>`)
+				return
+			}
+			fmt.Fprintf(w, `<DescribeStackEventsResponse><DescribeStackEventsResult><StackEvents><member>
+<StackId>%[1]s</StackId><StackName>stack-name</StackName><EventId>1</EventId>
+<LogicalResourceId>stack-name</LogicalResourceId><PhysicalResourceId>%[1]s</PhysicalResourceId>
+<ResourceType>AWS::CloudFormation::Stack</ResourceType><ResourceStatus>CREATE_COMPLETE</ResourceStatus>
+<Timestamp>2019-12-01T10:00:00Z</Timestamp><ClientRequestToken>token</ClientRequestToken>
+</member></StackEvents></DescribeStackEventsResult></DescribeStackEventsResponse>`, stackId)
+		case "DescribeStacks":
+			fmt.Fprintf(w, `<DescribeStacksResponse><DescribeStacksResult><Stacks><member>
+<StackId>%s</StackId><StackName>stack-name</StackName><StackStatus>CREATE_COMPLETE</StackStatus>
+<CreationTime>2019-12-01T10:00:00Z</CreationTime>
+</member></Stacks></DescribeStacksResult></DescribeStacksResponse>`, stackId)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	config := aws.NewConfig().
+		WithEndpoint(server.URL).
+		WithRegion("ap-southeast-2").
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", ""))
+	sess := session.Must(session.NewSession(request.WithRetryer(config, policy.Retryer())))
+
+	s := NewStackit(cloudformation.New(sess), &mockSts{})
+	s.SetRetryPolicy(policy)
+	s.pollInterval = time.Millisecond
+
+	statuses := []string{}
+	final, err := s.PollStackEvents(context.Background(), stackId, "token", func(event TailStackEvent) {
+		statuses = append(statuses, *event.ResourceStatus)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE_COMPLETE", *final.ResourceStatus)
+	assert.Equal(t, []string{"CREATE_COMPLETE"}, statuses)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&eventPolls))
+}
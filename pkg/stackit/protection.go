@@ -0,0 +1,28 @@
+package stackit
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+)
+
+// TerminationProtectedError is returned by Down when the stack has
+// termination protection enabled.
+type TerminationProtectedError struct {
+	StackName string
+}
+
+func (e *TerminationProtectedError) Error() string {
+	return fmt.Sprintf("stack %s has termination protection enabled and won't be deleted", e.StackName)
+}
+
+// SetTerminationProtection enables or disables termination protection on an
+// existing stack.
+func (s *Stackit) SetTerminationProtection(ctx context.Context, stackName string, enabled bool) error {
+	_, err := s.api.UpdateTerminationProtectionWithContext(ctx, &cloudformation.UpdateTerminationProtectionInput{
+		StackName:                   &stackName,
+		EnableTerminationProtection: &enabled,
+	})
+	return errors.Wrap(err, "updating termination protection")
+}
@@ -0,0 +1,37 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestDownRefusesProtectedStack(t *testing.T) {
+	output := describeStacksOutput(cloudformation.StackStatusUpdateComplete)
+	output.Stacks[0].EnableTerminationProtection = aws.Bool(true)
+
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(output, nil)
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.Down(context.Background(), "stack-name", make(chan TailStackEvent))
+	assert.IsType(t, &TerminationProtectedError{}, err)
+	assert.EqualError(t, err, "stack stack-name has termination protection enabled and won't be deleted")
+	capi.AssertNotCalled(t, "DeleteStackWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSetTerminationProtection(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("UpdateTerminationProtectionWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.UpdateTerminationProtectionOutput{}, nil).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.UpdateTerminationProtectionInput)
+		assert.Equal(t, "stack-name", *input.StackName)
+		assert.False(t, *input.EnableTerminationProtection)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.SetTerminationProtection(context.Background(), "stack-name", false)
+	assert.NoError(t, err)
+}
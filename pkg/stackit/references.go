@@ -0,0 +1,113 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+var parameterReference = regexp.MustCompile(`\{\{(stack|export):([^}]+)\}\}`)
+
+// ResolveParameterReferences replaces references to other stacks' outputs
+// ({{stack:<stack-name>.<OutputKey>}}) and to exported values
+// ({{export:<ExportName>}}) in parameter values with their current values.
+func (s *Stackit) ResolveParameterReferences(ctx context.Context, params []*cloudformation.Parameter) error {
+	r := &referenceResolver{sit: s, outputs: map[string]map[string]string{}}
+
+	for _, param := range params {
+		if param.ParameterValue == nil {
+			continue
+		}
+
+		var err error
+		resolved := parameterReference.ReplaceAllStringFunc(*param.ParameterValue, func(ref string) string {
+			if err != nil {
+				return ref
+			}
+
+			match := parameterReference.FindStringSubmatch(ref)
+			var value string
+			value, err = r.resolve(ctx, match[1], match[2])
+			return value
+		})
+
+		if err != nil {
+			return errors.Wrapf(err, "resolving parameter %s", *param.ParameterKey)
+		}
+
+		param.ParameterValue = &resolved
+	}
+
+	return nil
+}
+
+type referenceResolver struct {
+	sit     *Stackit
+	outputs map[string]map[string]string
+	exports map[string]string
+}
+
+func (r *referenceResolver) resolve(ctx context.Context, kind, ref string) (string, error) {
+	if kind == "export" {
+		return r.export(ctx, ref)
+	}
+
+	parts := strings.SplitN(ref, ".", 2)
+	if len(parts) != 2 {
+		return "", errors.Errorf("stack reference %s must be of the form <stack-name>.<OutputKey>", ref)
+	}
+
+	return r.output(ctx, parts[0], parts[1])
+}
+
+func (r *referenceResolver) output(ctx context.Context, stackName, key string) (string, error) {
+	outputs, ok := r.outputs[stackName]
+	if !ok {
+		stack, err := r.sit.Describe(ctx, stackName)
+		if err != nil {
+			return "", err
+		}
+
+		if stack == nil {
+			return "", errors.Errorf("stack %s does not exist", stackName)
+		}
+
+		outputs = map[string]string{}
+		for _, output := range stack.Outputs {
+			outputs[*output.OutputKey] = *output.OutputValue
+		}
+		r.outputs[stackName] = outputs
+	}
+
+	value, ok := outputs[key]
+	if !ok {
+		return "", errors.Errorf("stack %s has no output named %s", stackName, key)
+	}
+
+	return value, nil
+}
+
+func (r *referenceResolver) export(ctx context.Context, name string) (string, error) {
+	if r.exports == nil {
+		exports := map[string]string{}
+		err := r.sit.api.ListExportsPagesWithContext(ctx, &cloudformation.ListExportsInput{}, func(page *cloudformation.ListExportsOutput, lastPage bool) bool {
+			for _, export := range page.Exports {
+				exports[*export.Name] = *export.Value
+			}
+			return !lastPage
+		})
+		if err != nil {
+			return "", errors.Wrap(err, "listing exports")
+		}
+		r.exports = exports
+	}
+
+	value, ok := r.exports[name]
+	if !ok {
+		return "", errors.Errorf("export %s does not exist", name)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,72 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func referencesApi() *mockCfn {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.MatchedBy(func(input *cloudformation.DescribeStacksInput) bool {
+		return *input.StackName == "network-prod"
+	}), mock.Anything).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{{
+			StackId: aws.String("network-prod-id"),
+			Outputs: []*cloudformation.Output{
+				{OutputKey: aws.String("VpcId"), OutputValue: aws.String("vpc-123")},
+			},
+		}},
+	}, nil)
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("ListExportsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		cb := args.Get(2).(func(*cloudformation.ListExportsOutput, bool) bool)
+		cb(&cloudformation.ListExportsOutput{
+			Exports: []*cloudformation.Export{
+				{Name: aws.String("SharedVpcId"), Value: aws.String("vpc-456")},
+			},
+		}, true)
+	})
+	return capi
+}
+
+func TestResolveParameterReferences(t *testing.T) {
+	s := NewStackit(referencesApi(), &mockSts{})
+
+	params := []*cloudformation.Parameter{
+		{ParameterKey: aws.String("VpcId"), ParameterValue: aws.String("{{stack:network-prod.VpcId}}")},
+		{ParameterKey: aws.String("Shared"), ParameterValue: aws.String("{{export:SharedVpcId}},{{stack:network-prod.VpcId}}")},
+		{ParameterKey: aws.String("Plain"), ParameterValue: aws.String("value")},
+		{ParameterKey: aws.String("Previous"), UsePreviousValue: aws.Bool(true)},
+	}
+
+	err := s.ResolveParameterReferences(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, "vpc-123", *params[0].ParameterValue)
+	assert.Equal(t, "vpc-456,vpc-123", *params[1].ParameterValue)
+	assert.Equal(t, "value", *params[2].ParameterValue)
+	assert.Nil(t, params[3].ParameterValue)
+}
+
+func TestResolveParameterReferencesErrors(t *testing.T) {
+	tests := map[string]string{
+		"{{stack:missing-stack.VpcId}}":   "resolving parameter Param: stack missing-stack does not exist",
+		"{{stack:network-prod.SubnetId}}": "resolving parameter Param: stack network-prod has no output named SubnetId",
+		"{{stack:network-prod}}":          "resolving parameter Param: stack reference network-prod must be of the form <stack-name>.<OutputKey>",
+		"{{export:Nope}}":                 "resolving parameter Param: export Nope does not exist",
+	}
+
+	for value, expected := range tests {
+		t.Run(value, func(t *testing.T) {
+			s := NewStackit(referencesApi(), &mockSts{})
+			params := []*cloudformation.Parameter{
+				{ParameterKey: aws.String("Param"), ParameterValue: aws.String(value)},
+			}
+			assert.EqualError(t, s.ResolveParameterReferences(context.Background(), params), expected)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Package retry decides how throttled AWS API calls are retried and how
+// polling backs off when throttled. A single Policy is shared by stackit and
+// its subpackages so that they all behave the same under load.
+//
+// Throttled calls are retried by exactly one layer. Most calls are retried by
+// the AWS SDK, using the policy's Retryer. Polls of long-running operations
+// are made WithoutThrottlingRetries, so that throttled polls are only retried
+// by a Poller rather than MaxAttempts times by each layer. Other errors, such
+// as 5xx responses and dropped connections, are still retried by the SDK.
+package retry
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/pkg/errors"
+	"math/rand"
+	"time"
+)
+
+type Policy struct {
+	// MaxAttempts is the most times a throttled call is attempted before its
+	// error is returned.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. It doubles for each
+	// subsequent retry, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var DefaultPolicy = Policy{
+	MaxAttempts: 10,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// Delay returns how long to wait before the given retry, counting from one.
+// Half of the delay is random, so that many clients throttled at once don't
+// retry at once.
+func (p Policy) Delay(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.MaxDelay
+	if retry < 32 {
+		if exp := p.BaseDelay << uint(retry-1); exp > 0 && exp < p.MaxDelay {
+			delay = exp
+		}
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// Retryer returns a retryer for AWS SDK clients that follows the policy.
+func (p Policy) Retryer() request.Retryer {
+	return client.DefaultRetryer{
+		NumMaxRetries:    p.MaxAttempts - 1,
+		MinRetryDelay:    p.BaseDelay,
+		MinThrottleDelay: p.BaseDelay,
+		MaxRetryDelay:    p.MaxDelay,
+		MaxThrottleDelay: p.MaxDelay,
+	}
+}
+
+// WithoutThrottlingRetries is a request option that stops the AWS SDK
+// retrying a call that was throttled, for polls whose throttling is handled
+// by a Poller instead. Other errors are retried by the request's retryer as
+// usual.
+func WithoutThrottlingRetries(r *request.Request) {
+	r.Retryer = throttlingExcluded{Retryer: r.Retryer}
+	r.Config.EnforceShouldRetryCheck = aws.Bool(true)
+}
+
+type throttlingExcluded struct {
+	request.Retryer
+}
+
+func (t throttlingExcluded) ShouldRetry(r *request.Request) bool {
+	if r.IsErrorThrottle() {
+		return false
+	}
+	return t.Retryer.ShouldRetry(r)
+}
+
+// IsThrottling reports whether err was caused by an AWS API throttling the
+// request.
+func IsThrottling(err error) bool {
+	return err != nil && request.IsErrorThrottle(errors.Cause(err))
+}
+
+// Poller spaces out the polls of a long-running operation, and retries polls
+// that were throttled. Polls should be made WithoutThrottlingRetries.
+type Poller struct {
+	policy    Policy
+	interval  time.Duration
+	throttled int
+}
+
+// Poller returns a poller that polls every interval, backing off according
+// to the policy while polls are throttled.
+func (p Policy) Poller(interval time.Duration) *Poller {
+	return &Poller{policy: p, interval: interval}
+}
+
+// Wait waits until it's time for the next poll. err is the error returned by
+// the previous poll, if any. Errors other than throttling are returned
+// immediately, as is a throttling error once MaxAttempts polls in a row have
+// been throttled.
+func (p *Poller) Wait(ctx context.Context, err error) error {
+	delay := p.interval
+
+	if err == nil {
+		p.throttled = 0
+	} else if !IsThrottling(err) {
+		return err
+	} else {
+		p.throttled++
+		if p.throttled >= p.policy.MaxAttempts {
+			return err
+		}
+
+		if backoff := p.policy.Delay(p.throttled); backoff > delay {
+			delay = backoff
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	for retry, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 10 * time.Second, 64: 10 * time.Second} {
+		for i := 0; i < 100; i++ {
+			delay := policy.Delay(retry)
+			assert.True(t, delay >= max/2 && delay <= max, "retry %d delay %s should be between %s and %s", retry, delay, max/2, max)
+		}
+	}
+
+	assert.Equal(t, time.Duration(0), Policy{}.Delay(1))
+}
+
+func TestWithoutThrottlingRetries(t *testing.T) {
+	r := &request.Request{Retryer: DefaultPolicy.Retryer()}
+	r.ApplyOptions(WithoutThrottlingRetries)
+	assert.Equal(t, DefaultPolicy.MaxAttempts-1, r.MaxRetries())
+
+	r.Error = awserr.NewRequestFailure(awserr.New("InternalFailure", "oops", nil), 500, "")
+	r.HTTPResponse = &http.Response{StatusCode: 500}
+	assert.True(t, r.ShouldRetry(r), "server errors are retried")
+
+	r.Error = awserr.New("RequestError", "connection reset", nil)
+	r.HTTPResponse = nil
+	assert.True(t, r.ShouldRetry(r), "connection errors are retried")
+
+	r.Error = awserr.NewRequestFailure(awserr.New("Throttling", "Rate exceeded", nil), 400, "")
+	r.HTTPResponse = &http.Response{StatusCode: 400}
+	assert.False(t, r.ShouldRetry(r), "throttling is left to the poller")
+}
+
+func TestIsThrottling(t *testing.T) {
+	throttled := awserr.New("Throttling", "Rate exceeded", nil)
+	assert.True(t, IsThrottling(throttled))
+	assert.True(t, IsThrottling(pkgerrors.Wrap(throttled, "describing stack")))
+	assert.False(t, IsThrottling(awserr.New("ValidationError", "Stack does not exist", nil)))
+	assert.False(t, IsThrottling(nil))
+}
+
+func TestPoller_Wait(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	poller := policy.Poller(time.Millisecond)
+	ctx := context.Background()
+	throttled := awserr.New("ThrottlingException", "Rate exceeded", nil)
+
+	assert.NoError(t, poller.Wait(ctx, nil))
+	assert.NoError(t, poller.Wait(ctx, throttled))
+	assert.NoError(t, poller.Wait(ctx, throttled))
+	assert.Equal(t, throttled, poller.Wait(ctx, throttled), "gives up after MaxAttempts throttled polls in a row")
+
+	poller = policy.Poller(time.Millisecond)
+	assert.NoError(t, poller.Wait(ctx, throttled))
+	assert.NoError(t, poller.Wait(ctx, nil))
+	assert.NoError(t, poller.Wait(ctx, throttled), "a successful poll resets the count")
+
+	other := errors.New("access denied")
+	assert.Equal(t, other, poller.Wait(ctx, other))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, policy.Poller(time.Hour).Wait(cancelled, nil))
+}
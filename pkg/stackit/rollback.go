@@ -0,0 +1,75 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+	"regexp"
+)
+
+const (
+	rollbackTriggerType = "AWS::CloudWatch::Alarm"
+
+	// maxRollbackMonitoringMinutes is the longest monitoring period
+	// CloudFormation allows.
+	maxRollbackMonitoringMinutes = 180
+)
+
+var alarmArnPattern = regexp.MustCompile(`arn:aws[a-z-]*:cloudwatch:[a-z0-9-]+:[0-9]+:alarm:[^\s,\]"]+`)
+
+// rollbackConfiguration returns the rollback configuration for a change set.
+// RollbackTriggers is left unset when there are no alarms, as an empty list
+// would remove the stack's existing triggers.
+func rollbackConfiguration(alarmArns []string, monitoringMinutes int64) (*cloudformation.RollbackConfiguration, error) {
+	if len(alarmArns) == 0 && monitoringMinutes == 0 {
+		return nil, nil
+	}
+
+	if monitoringMinutes < 0 || monitoringMinutes > maxRollbackMonitoringMinutes {
+		return nil, errors.Errorf("rollback monitoring time must be between 0 and %d minutes", maxRollbackMonitoringMinutes)
+	}
+
+	var triggers []*cloudformation.RollbackTrigger
+	for _, arn := range alarmArns {
+		triggers = append(triggers, &cloudformation.RollbackTrigger{
+			Arn:  aws.String(arn),
+			Type: aws.String(rollbackTriggerType),
+		})
+	}
+
+	return &cloudformation.RollbackConfiguration{
+		RollbackTriggers:        triggers,
+		MonitoringTimeInMinutes: &monitoringMinutes,
+	}, nil
+}
+
+// TriggeredRollbackAlarms returns the ARNs of the alarms that caused the
+// stack's most recent operation to roll back. It returns nothing if the
+// operation didn't roll back or was rolled back for another reason.
+func (s *Stackit) TriggeredRollbackAlarms(ctx context.Context, stackId string) ([]string, error) {
+	var reason string
+
+	err := s.api.DescribeStackEventsPagesWithContext(ctx, &cloudformation.DescribeStackEventsInput{StackName: &stackId}, func(page *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+		for _, event := range page.StackEvents {
+			if aws.StringValue(event.PhysicalResourceId) != aws.StringValue(event.StackId) {
+				continue
+			}
+
+			switch aws.StringValue(event.ResourceStatus) {
+			case cloudformation.ResourceStatusUpdateInProgress, cloudformation.ResourceStatusCreateInProgress:
+				// reached the start of the operation without finding a rollback
+				return false
+			case cloudformation.StackStatusUpdateRollbackInProgress, cloudformation.StackStatusRollbackInProgress:
+				reason = aws.StringValue(event.ResourceStatusReason)
+				return false
+			}
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "describing stack events")
+	}
+
+	return alarmArnPattern.FindAllString(reason, -1), nil
+}
@@ -0,0 +1,82 @@
+package stackit
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestPrepareSetsRollbackConfiguration(t *testing.T) {
+	alarm := "arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:api-5xx"
+
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CreateChangeSetInput)
+		assert.Equal(t, &cloudformation.RollbackConfiguration{
+			MonitoringTimeInMinutes: aws.Int64(10),
+			RollbackTriggers: []*cloudformation.RollbackTrigger{
+				{Arn: &alarm, Type: aws.String("AWS::CloudWatch::Alarm")},
+			},
+		}, input.RollbackConfiguration)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{
+		StackName:                 "stack-name",
+		RollbackAlarmARNs:         []string{alarm},
+		RollbackMonitoringMinutes: 10,
+	}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "creating change set: done")
+}
+
+func TestRollbackMonitoringMinutesIsValidated(t *testing.T) {
+	_, err := rollbackConfiguration(nil, 181)
+	assert.EqualError(t, err, "rollback monitoring time must be between 0 and 180 minutes")
+
+	config, err := rollbackConfiguration(nil, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+}
+
+func TestRollbackMonitoringWithoutAlarmsKeepsExistingTriggers(t *testing.T) {
+	config, err := rollbackConfiguration(nil, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &cloudformation.RollbackConfiguration{MonitoringTimeInMinutes: aws.Int64(10)}, config)
+	assert.Nil(t, config.RollbackTriggers)
+}
+
+func TestTriggeredRollbackAlarms(t *testing.T) {
+	stackId := "arn:aws:cloudformation:ap-southeast-2:123456789012:stack/stack-name/abc"
+	stackEvent := func(status, reason string) *cloudformation.StackEvent {
+		return &cloudformation.StackEvent{
+			StackId:              &stackId,
+			PhysicalResourceId:   &stackId,
+			ResourceStatus:       &status,
+			ResourceStatusReason: &reason,
+		}
+	}
+
+	capi := &mockCfn{}
+	capi.On("DescribeStackEventsPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		cb := args.Get(2).(func(*cloudformation.DescribeStackEventsOutput, bool) bool)
+		cb(&cloudformation.DescribeStackEventsOutput{
+			StackEvents: []*cloudformation.StackEvent{
+				stackEvent(cloudformation.StackStatusUpdateRollbackComplete, ""),
+				stackEvent(cloudformation.StackStatusUpdateRollbackInProgress, "Stack was rolled back because the following rollback triggers fired: [arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:api-5xx]"),
+				stackEvent(cloudformation.StackStatusUpdateInProgress, "User Initiated"),
+			},
+		}, true)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	alarms, err := s.TriggeredRollbackAlarms(context.Background(), stackId)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"arn:aws:cloudwatch:ap-southeast-2:123456789012:alarm:api-5xx"}, alarms)
+}
@@ -0,0 +1,47 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/pkg/errors"
+)
+
+// signallableResourceTypes are the resource types that can have a
+// CreationPolicy, UpdatePolicy or are otherwise waiting on signals.
+var signallableResourceTypes = []string{
+	"AWS::AppStream::Fleet",
+	"AWS::AutoScaling::AutoScalingGroup",
+	"AWS::CloudFormation::WaitCondition",
+	"AWS::EC2::Instance",
+}
+
+func (s *Stackit) Signal(ctx context.Context, stackName, logicalId, uniqueId, status string) error {
+	if status != cloudformation.ResourceSignalStatusSuccess && status != cloudformation.ResourceSignalStatusFailure {
+		return errors.Errorf("signal status must be %s or %s, not %s", cloudformation.ResourceSignalStatusSuccess, cloudformation.ResourceSignalStatusFailure, status)
+	}
+
+	if uniqueId == "" {
+		return errors.New("signal unique id must not be empty")
+	}
+
+	resp, err := s.api.DescribeStackResourceWithContext(ctx, &cloudformation.DescribeStackResourceInput{
+		StackName:         &stackName,
+		LogicalResourceId: &logicalId,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "resolving resource %s in stack %s", logicalId, stackName)
+	}
+
+	resource := resp.StackResourceDetail
+	if !stringInSlice(signallableResourceTypes, *resource.ResourceType) {
+		return errors.Errorf("resource %s has type %s, which does not accept signals", logicalId, *resource.ResourceType)
+	}
+
+	_, err = s.api.SignalResourceWithContext(ctx, &cloudformation.SignalResourceInput{
+		StackName:         resource.StackId,
+		LogicalResourceId: resource.LogicalResourceId,
+		UniqueId:          &uniqueId,
+		Status:            &status,
+	})
+	return errors.Wrap(err, "signalling resource")
+}
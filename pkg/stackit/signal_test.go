@@ -0,0 +1,53 @@
+package stackit
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func describeStackResourceOutput(resourceType string) *cloudformation.DescribeStackResourceOutput {
+	return &cloudformation.DescribeStackResourceOutput{
+		StackResourceDetail: &cloudformation.StackResourceDetail{
+			StackId:           aws.String("stack-id"),
+			LogicalResourceId: aws.String("Resource"),
+			ResourceType:      aws.String(resourceType),
+		},
+	}
+}
+
+func TestSignal(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStackResourceWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStackResourceOutput("AWS::CloudFormation::WaitCondition"), nil)
+	capi.On("SignalResourceWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.SignalResourceOutput{}, nil).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.SignalResourceInput)
+		assert.Equal(t, "stack-id", *input.StackName)
+		assert.Equal(t, "Resource", *input.LogicalResourceId)
+		assert.Equal(t, "i-abc123", *input.UniqueId)
+		assert.Equal(t, "SUCCESS", *input.Status)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.Signal(context.Background(), "stack-name", "Resource", "i-abc123", "SUCCESS")
+	assert.NoError(t, err)
+	capi.AssertCalled(t, "SignalResourceWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSignalRejectsUnsupportedResourceType(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStackResourceWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStackResourceOutput("AWS::S3::Bucket"), nil)
+
+	s := NewStackit(capi, &mockSts{})
+	err := s.Signal(context.Background(), "stack-name", "Resource", "i-abc123", "SUCCESS")
+	assert.EqualError(t, err, "resource Resource has type AWS::S3::Bucket, which does not accept signals")
+	capi.AssertNotCalled(t, "SignalResourceWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSignalRejectsInvalidStatus(t *testing.T) {
+	s := NewStackit(&mockCfn{}, &mockSts{})
+	err := s.Signal(context.Background(), "stack-name", "Resource", "i-abc123", "MAYBE")
+	assert.EqualError(t, err, "signal status must be SUCCESS or FAILURE, not MAYBE")
+}
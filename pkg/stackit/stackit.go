@@ -8,30 +8,45 @@ import (
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/glassechidna/awsctx/service/cloudformationctx"
 	"github.com/glassechidna/awsctx/service/stsctx"
+	"github.com/glassechidna/stackit/pkg/stackit/retry"
 	"github.com/pkg/errors"
 	"io"
 	"log"
+	"sync"
+	"time"
 )
 
 type Stackit struct {
-	api    cloudformationctx.CloudFormation
-	stsApi stsctx.STS
+	api      cloudformationctx.CloudFormation
+	stsApi   stsctx.STS
+	uploader TemplateUploader
+	retry    retry.Policy
+
+	// pollInterval is how often PollStackEvents polls for new events.
+	pollInterval time.Duration
+
+	// recorded holds the events of the most recent operation performed on
+	// each stack, for FailureSummary.
+	recorded   map[string][]TailStackEvent
+	recordedMu sync.Mutex
 }
 
 func NewStackit(api cloudformationctx.CloudFormation, stsApi stsctx.STS) *Stackit {
-	return &Stackit{api: api, stsApi: stsApi}
+	return &Stackit{api: api, stsApi: stsApi, retry: retry.DefaultPolicy, pollInterval: 3 * time.Second}
+}
+
+// SetRetryPolicy sets how polling for the progress of stack operations backs
+// off when throttled. Throttled API calls are retried by the API clients, so
+// they should be configured with the same policy's Retryer.
+func (s *Stackit) SetRetryPolicy(policy retry.Policy) {
+	s.retry = policy
 }
 
 func (s *Stackit) Describe(ctx context.Context, stackName string) (*cloudformation.Stack, error) {
 	resp, err := s.api.DescribeStacksWithContext(ctx, &cloudformation.DescribeStacksInput{StackName: &stackName})
 	if err != nil {
-		if awsErr, ok := err.(awserr.Error); ok {
-			code := awsErr.Code()
-			if code == "ThrottlingException" {
-				return s.Describe(ctx, stackName)
-			} else if code == "ValidationError" {
-				return nil, nil
-			}
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "ValidationError" {
+			return nil, nil
 		}
 		return nil, errors.Wrap(err, "determining stack status")
 	}
@@ -40,11 +55,32 @@ func (s *Stackit) Describe(ctx context.Context, stackName string) (*cloudformati
 	return stack, nil
 }
 
-func (s *Stackit) PrintOutputs(ctx context.Context, stackName string, writer io.Writer) {
-	stack, err := s.Describe(ctx, stackName)
+// ResourceTypes returns the type of each of the stack's resources, keyed by
+// logical ID. The stack itself is included, as it appears in stack events.
+func (s *Stackit) ResourceTypes(ctx context.Context, stackName string) (map[string]string, error) {
+	types := map[string]string{stackName: "AWS::CloudFormation::Stack"}
 
+	err := s.api.ListStackResourcesPagesWithContext(ctx, &cloudformation.ListStackResourcesInput{StackName: &stackName}, func(page *cloudformation.ListStackResourcesOutput, lastPage bool) bool {
+		for _, resource := range page.StackResourceSummaries {
+			types[*resource.LogicalResourceId] = *resource.ResourceType
+		}
+		return !lastPage
+	})
 	if err != nil {
-		log.Fatal(err.Error())
+		return nil, errors.Wrap(err, "listing stack resources")
+	}
+
+	return types, nil
+}
+
+func (s *Stackit) Outputs(ctx context.Context, stackName string) (map[string]string, error) {
+	stack, err := s.Describe(ctx, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	if stack == nil {
+		return nil, errors.Errorf("stack %s does not exist", stackName)
 	}
 
 	outputMap := make(map[string]string)
@@ -53,6 +89,16 @@ func (s *Stackit) PrintOutputs(ctx context.Context, stackName string, writer io.
 		outputMap[*output.OutputKey] = *output.OutputValue
 	}
 
+	return outputMap, nil
+}
+
+func (s *Stackit) PrintOutputs(ctx context.Context, stackName string, writer io.Writer) {
+	outputMap, err := s.Outputs(ctx, stackName)
+
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
 	bytes, err := json.MarshalIndent(outputMap, "", "  ")
 	fmt.Fprintln(writer, string(bytes))
 }
@@ -64,5 +110,5 @@ func (s *Stackit) IsSuccessfulState(ctx context.Context, stackName string) (bool
 	}
 
 	status := *stack.StackStatus
-	return status == "CREATE_COMPLETE" || status == "UPDATE_COMPLETE", nil
+	return status == "CREATE_COMPLETE" || status == "UPDATE_COMPLETE" || status == "IMPORT_COMPLETE", nil
 }
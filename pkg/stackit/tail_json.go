@@ -0,0 +1,111 @@
+package stackit
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"io"
+	"sync"
+	"time"
+)
+
+// TailEventRecord is the newline-delimited JSON representation of a stack
+// event printed by a JSON tail printer.
+type TailEventRecord struct {
+	Type           string    `json:"type"`
+	StackName      string    `json:"stackName"`
+	StackId        string    `json:"stackId"`
+	NestedPath     []string  `json:"nestedPath,omitempty"`
+	LogicalId      string    `json:"logicalId"`
+	PhysicalId     string    `json:"physicalId"`
+	ResourceType   string    `json:"resourceType"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	ElapsedSeconds float64   `json:"elapsedSeconds"`
+}
+
+// TailSummaryRecord is printed by a JSON tail printer once the stack
+// operation has finished.
+type TailSummaryRecord struct {
+	Type           string            `json:"type"`
+	StackName      string            `json:"stackName"`
+	StackId        string            `json:"stackId,omitempty"`
+	Status         string            `json:"status,omitempty"`
+	Success        bool              `json:"success"`
+	Events         int               `json:"events"`
+	ElapsedSeconds float64           `json:"elapsedSeconds"`
+	Outputs        map[string]string `json:"outputs,omitempty"`
+	Failure        *FailureSummary   `json:"failure,omitempty"`
+}
+
+const (
+	tailRecordTypeEvent   = "event"
+	tailRecordTypeSummary = "summary"
+)
+
+// jsonTailState is shared between copies of a TailPrinter so that the
+// summary can report on every event printed.
+type jsonTailState struct {
+	mu      sync.Mutex
+	first   *time.Time
+	started time.Time
+	events  int
+}
+
+// NewJsonTailPrinter returns a printer that prints each event as a line of
+// JSON, for consumption by other programs.
+func NewJsonTailPrinter(writer io.Writer) TailPrinter {
+	return TailPrinter{
+		writer: writer,
+		json:   &jsonTailState{},
+	}
+}
+
+func (tp *TailPrinter) formatJsonTailEvent(tailEvent TailStackEvent) string {
+	tp.json.mu.Lock()
+	if tp.json.first == nil {
+		tp.json.first = tailEvent.Timestamp
+		tp.json.started = time.Now()
+	}
+	tp.json.events++
+	first := *tp.json.first
+	tp.json.mu.Unlock()
+
+	timestamp := aws.TimeValue(tailEvent.Timestamp)
+	record := TailEventRecord{
+		Type:           tailRecordTypeEvent,
+		StackName:      aws.StringValue(tailEvent.StackName),
+		StackId:        aws.StringValue(tailEvent.StackId),
+		NestedPath:     tailEvent.NestedPath,
+		LogicalId:      aws.StringValue(tailEvent.LogicalResourceId),
+		PhysicalId:     aws.StringValue(tailEvent.PhysicalResourceId),
+		ResourceType:   aws.StringValue(tailEvent.ResourceType),
+		Status:         aws.StringValue(tailEvent.ResourceStatus),
+		Reason:         aws.StringValue(tailEvent.ResourceStatusReason),
+		Timestamp:      timestamp,
+		ElapsedSeconds: timestamp.Sub(first).Seconds(),
+	}
+
+	body, _ := json.Marshal(record)
+	return string(body)
+}
+
+// PrintSummary prints a final summary record of the events printed so far.
+// It does nothing for printers that aren't printing JSON.
+func (tp *TailPrinter) PrintSummary(summary TailSummaryRecord) {
+	if tp.json == nil {
+		return
+	}
+
+	tp.json.mu.Lock()
+	summary.Type = tailRecordTypeSummary
+	summary.Events = tp.json.events
+	if tp.json.first != nil {
+		summary.ElapsedSeconds = time.Since(tp.json.started).Seconds()
+	}
+	tp.json.mu.Unlock()
+
+	body, _ := json.Marshal(summary)
+	fmt.Fprintln(tp.writer, string(body))
+}
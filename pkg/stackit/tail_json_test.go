@@ -0,0 +1,57 @@
+package stackit
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJsonTailPrinter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	printer := NewJsonTailPrinter(buf)
+
+	start := time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)
+	event := func(logicalId, status string, offset time.Duration) TailStackEvent {
+		return TailStackEvent{StackEvent: cloudformation.StackEvent{
+			StackName:          aws.String("stack-name"),
+			StackId:            aws.String("stack-id"),
+			LogicalResourceId:  aws.String(logicalId),
+			PhysicalResourceId: aws.String("physical-" + logicalId),
+			ResourceType:       aws.String("AWS::SQS::Queue"),
+			ResourceStatus:     aws.String(status),
+			Timestamp:          aws.Time(start.Add(offset)),
+		}}
+	}
+
+	printer.PrintTailEvent(event("Queue", "CREATE_IN_PROGRESS", 0))
+	printer.PrintTailEvent(event("Queue", "CREATE_COMPLETE", 12*time.Second))
+	printer.PrintSummary(TailSummaryRecord{StackName: "stack-name", Status: "CREATE_COMPLETE", Success: true})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+
+	record := TailEventRecord{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, TailEventRecord{
+		Type:           "event",
+		StackName:      "stack-name",
+		StackId:        "stack-id",
+		LogicalId:      "Queue",
+		PhysicalId:     "physical-Queue",
+		ResourceType:   "AWS::SQS::Queue",
+		Status:         "CREATE_COMPLETE",
+		Timestamp:      start.Add(12 * time.Second),
+		ElapsedSeconds: 12,
+	}, record)
+
+	summary := TailSummaryRecord{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &summary))
+	assert.Equal(t, "summary", summary.Type)
+	assert.Equal(t, 2, summary.Events)
+	assert.True(t, summary.Success)
+}
@@ -7,31 +7,102 @@ import (
 	"strings"
 )
 
+// defaultResourceNameLength is the width of the logical ID column when the
+// stack's resources aren't known.
+const defaultResourceNameLength = 20
+
+// minimumReasonWidth is the narrowest a wrapped status reason can be before
+// wrapping is abandoned.
+const minimumReasonWidth = 20
+
 type TailPrinter struct {
-	timestampFormat string
-	failureColor    *color.Color
-	writer          io.Writer
+	timestampFormat    string
+	failureColor       *color.Color
+	writer             io.Writer
+	prefix             string
+	json               *jsonTailState
+	options            TailPrinterOptions
+	resourceNameLength int
+	resourceTypeLength int
+}
+
+// TailPrinterOptions controls the columns printed for each event.
+type TailPrinterOptions struct {
+	ResourceType bool
+	PhysicalId   bool
+
+	// Width is the width of the terminal. Status reasons that would make
+	// lines longer than this are wrapped. Zero disables wrapping.
+	Width int
 }
 
 func NewTailPrinter(writer io.Writer) TailPrinter {
 	return TailPrinter{
-		timestampFormat: "[03:04:05]",
-		failureColor:    color.New(color.FgRed),
-		writer:          writer,
+		timestampFormat:    "[03:04:05]",
+		failureColor:       color.New(color.FgRed),
+		writer:             writer,
+		resourceNameLength: defaultResourceNameLength,
+	}
+}
+
+// NewPrefixedTailPrinter returns a printer that prefixes every line with the
+// given string (e.g. a stack name) to distinguish events from several stacks
+// printed to the same writer.
+func NewPrefixedTailPrinter(writer io.Writer, prefix string) TailPrinter {
+	tp := NewTailPrinter(writer)
+	tp.prefix = fmt.Sprintf("[%s] ", prefix)
+	return tp
+}
+
+func (tp *TailPrinter) SetOptions(options TailPrinterOptions) {
+	tp.options = options
+}
+
+// FitResources sizes the logical ID and resource type columns to fit the
+// given resources, keyed by logical ID with their types as values.
+func (tp *TailPrinter) FitResources(resources map[string]string) {
+	if len(resources) == 0 {
+		return
+	}
+
+	tp.resourceNameLength, tp.resourceTypeLength = 0, 0
+	for name, typ := range resources {
+		if len(name) > tp.resourceNameLength {
+			tp.resourceNameLength = len(name)
+		}
+		if len(typ) > tp.resourceTypeLength {
+			tp.resourceTypeLength = len(typ)
+		}
 	}
 }
 
 func (tp *TailPrinter) FormatTailEvent(tailEvent TailStackEvent) string {
-	resourceNameLength := 20 // TODO: determine this from template/API
+	if tp.json != nil {
+		return tp.formatJsonTailEvent(tailEvent)
+	}
 
 	timestampPrefix := tailEvent.Timestamp.Format(tp.timestampFormat)
 
+	prefix := tp.prefix
+	if len(tailEvent.NestedPath) > 0 {
+		prefix = fmt.Sprintf("%s[%s] ", prefix, strings.Join(tailEvent.NestedPath, "/"))
+	}
+
+	head := fmt.Sprintf("%s %s%s", timestampPrefix, prefix, fixedLengthString(tp.resourceNameLength, *tailEvent.LogicalResourceId))
+	if tp.options.ResourceType && tailEvent.ResourceType != nil {
+		head = fmt.Sprintf("%s %-*s", head, tp.resourceTypeLength, *tailEvent.ResourceType)
+	}
+	head = fmt.Sprintf("%s - %s", head, *tailEvent.ResourceStatus)
+	if tp.options.PhysicalId && tailEvent.PhysicalResourceId != nil && *tailEvent.PhysicalResourceId != "" {
+		head = fmt.Sprintf("%s (%s)", head, *tailEvent.PhysicalResourceId)
+	}
+
 	reasonPart := ""
 	if tailEvent.ResourceStatusReason != nil {
-		reasonPart = fmt.Sprintf("- %s", *tailEvent.ResourceStatusReason)
+		reasonPart = fmt.Sprintf("- %s", wrapReason(*tailEvent.ResourceStatusReason, len(head)+3, tp.options.Width))
 	}
 
-	line := fmt.Sprintf("%s %s - %s %s", timestampPrefix, fixedLengthString(resourceNameLength, *tailEvent.LogicalResourceId), *tailEvent.ResourceStatus, reasonPart)
+	line := fmt.Sprintf("%s %s", head, reasonPart)
 
 	if isBadStatus(*tailEvent.ResourceStatus) && tp.failureColor != nil {
 		return tp.failureColor.Sprint(line)
@@ -45,6 +116,34 @@ func (tp *TailPrinter) PrintTailEvent(tailEvent TailStackEvent) {
 	fmt.Fprintln(tp.writer, line)
 }
 
+// wrapReason wraps reason so that no line is longer than width, given that
+// the first line starts at column indent. Continuation lines are indented to
+// line up with the first.
+func wrapReason(reason string, indent, width int) string {
+	available := width - indent
+	if width == 0 || available < minimumReasonWidth || len(reason) <= available {
+		return reason
+	}
+
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(reason) {
+		if line != "" && len(line)+1+len(word) > available {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if line == "" {
+			line = word
+		} else {
+			line += " " + word
+		}
+	}
+	lines = append(lines, line)
+
+	return strings.Join(lines, "\n"+strings.Repeat(" ", indent))
+}
+
 func fixedLengthString(length int, str string) string {
 	verb := fmt.Sprintf("%%%d.%ds", length, length)
 	return fmt.Sprintf(verb, str)
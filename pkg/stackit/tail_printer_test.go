@@ -0,0 +1,58 @@
+package stackit
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func tailPrinterTestEvent(reason *string) TailStackEvent {
+	return TailStackEvent{StackEvent: cloudformation.StackEvent{
+		LogicalResourceId:    aws.String("Queue"),
+		PhysicalResourceId:   aws.String("https://sqs/queue"),
+		ResourceType:         aws.String("AWS::SQS::Queue"),
+		ResourceStatus:       aws.String("CREATE_IN_PROGRESS"),
+		ResourceStatusReason: reason,
+		Timestamp:            aws.Time(time.Date(2019, 12, 1, 10, 0, 0, 0, time.UTC)),
+	}}
+}
+
+func TestTailPrinter_FormatTailEvent(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		printer := NewTailPrinter(nil)
+		printer.failureColor = nil
+		line := printer.FormatTailEvent(tailPrinterTestEvent(aws.String("Resource creation Initiated")))
+		assert.Equal(t, "[10:00:00]                Queue - CREATE_IN_PROGRESS - Resource creation Initiated", line)
+	})
+
+	t.Run("fitted columns", func(t *testing.T) {
+		printer := NewTailPrinter(nil)
+		printer.FitResources(map[string]string{
+			"Queue":    "AWS::SQS::Queue",
+			"Function": "AWS::Lambda::Function",
+		})
+		printer.SetOptions(TailPrinterOptions{ResourceType: true, PhysicalId: true})
+		line := printer.FormatTailEvent(tailPrinterTestEvent(nil))
+		assert.Equal(t, "[10:00:00]    Queue AWS::SQS::Queue       - CREATE_IN_PROGRESS (https://sqs/queue) ", line)
+	})
+
+	t.Run("wrapped reason", func(t *testing.T) {
+		printer := NewTailPrinter(nil)
+		printer.FitResources(map[string]string{"Queue": "AWS::SQS::Queue"})
+		printer.SetOptions(TailPrinterOptions{Width: 60})
+		line := printer.FormatTailEvent(tailPrinterTestEvent(aws.String("Resource handler returned message: queue already exists")))
+		assert.Equal(t, ""+
+			"[10:00:00] Queue - CREATE_IN_PROGRESS - Resource handler\n"+
+			"                                        returned message:\n"+
+			"                                        queue already exists", line)
+	})
+}
+
+func TestWrapReason(t *testing.T) {
+	assert.Equal(t, "short reason", wrapReason("short reason", 10, 80))
+	assert.Equal(t, "no width given", wrapReason("no width given", 70, 0))
+	assert.Equal(t, "too narrow to bother wrapping", wrapReason("too narrow to bother wrapping", 70, 80))
+	assert.Equal(t, "one two three four\n  five", wrapReason("one two three four five", 2, 22))
+}
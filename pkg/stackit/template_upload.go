@@ -0,0 +1,38 @@
+package stackit
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// maxTemplateBodySize is the largest template body, in bytes, that
+// CloudFormation accepts inline. Larger templates must be passed by URL.
+const maxTemplateBodySize = 51200
+
+// TemplateUploader uploads templates that are too large to pass inline and
+// returns the URL they can be retrieved from.
+type TemplateUploader interface {
+	UploadTemplate(ctx context.Context, prefix, body string) (string, error)
+}
+
+// SetTemplateUploader configures where oversized templates are uploaded. If
+// no uploader is set, templates are always passed inline.
+func (s *Stackit) SetTemplateUploader(uploader TemplateUploader) {
+	s.uploader = uploader
+}
+
+// templateLocation returns either the template body or, if it is too large
+// to pass inline, the URL it was uploaded to. Exactly one of the return
+// values is non-nil when err is nil.
+func (s *Stackit) templateLocation(ctx context.Context, prefix, body string) (*string, *string, error) {
+	if len(body) <= maxTemplateBodySize || s.uploader == nil {
+		return &body, nil, nil
+	}
+
+	url, err := s.uploader.UploadTemplate(ctx, prefix, body)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "uploading oversized template")
+	}
+
+	return nil, &url, nil
+}
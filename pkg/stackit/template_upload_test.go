@@ -0,0 +1,83 @@
+package stackit
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+)
+
+type fakeUploader struct {
+	prefix string
+	body   string
+}
+
+func (f *fakeUploader) UploadTemplate(ctx context.Context, prefix, body string) (string, error) {
+	f.prefix, f.body = prefix, body
+	return "https://bucket.s3.ap-southeast-2.amazonaws.com/template.yml", nil
+}
+
+func largeTemplate() string {
+	return "Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n" + "# " + strings.Repeat("x", maxTemplateBodySize) + "\n"
+}
+
+func TestPrepareUploadsOversizedTemplate(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CreateChangeSetInput)
+		assert.Nil(t, input.TemplateBody)
+		assert.Equal(t, "https://bucket.s3.ap-southeast-2.amazonaws.com/template.yml", *input.TemplateURL)
+	})
+
+	uploader := &fakeUploader{}
+	s := NewStackit(capi, &mockSts{})
+	s.SetTemplateUploader(uploader)
+
+	input := StackitUpInput{StackName: "stack-name", Template: stringTemplate(largeTemplate())}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "creating change set: done")
+	assert.Equal(t, "stack-name", uploader.prefix)
+	assert.Equal(t, largeTemplate(), uploader.body)
+}
+
+func TestTransformUploadsOversizedTemplateToStablePrefix(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CreateChangeSetInput)
+		assert.Nil(t, input.TemplateBody)
+		assert.Equal(t, "https://bucket.s3.ap-southeast-2.amazonaws.com/template.yml", *input.TemplateURL)
+	})
+
+	uploader := &fakeUploader{}
+	s := NewStackit(capi, &mockSts{})
+	s.SetTemplateUploader(uploader)
+
+	_, err := s.Transform(context.Background(), largeTemplate(), nil, nil)
+	assert.EqualError(t, err, "creating change set: done")
+	assert.Equal(t, transformPrefix, uploader.prefix)
+}
+
+func TestPrepareSendsSmallTemplateInline(t *testing.T) {
+	body := "Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n"
+
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.CreateChangeSetInput)
+		assert.Equal(t, body, *input.TemplateBody)
+		assert.Nil(t, input.TemplateURL)
+	})
+
+	uploader := &fakeUploader{}
+	s := NewStackit(capi, &mockSts{})
+	s.SetTemplateUploader(uploader)
+
+	_, err := s.Prepare(context.Background(), StackitUpInput{StackName: "stack-name", Template: stringTemplate(body)}, make(chan TailStackEvent))
+	assert.EqualError(t, err, "creating change set: done")
+	assert.Empty(t, uploader.body)
+}
@@ -10,7 +10,20 @@ import (
 	"time"
 )
 
-func (s *Stackit) Transform(ctx context.Context, template string, paramMap map[string]string) (*string, error) {
+// transformPrefix is where oversized templates are uploaded for Transform.
+// Unlike the temporary stack's name it is stable, so that transforming the
+// same template again reuses the object already uploaded.
+const transformPrefix = "stackit-transform"
+
+// Transform returns the processed template body. If capabilities is nil,
+// DefaultCapabilities are granted.
+func (s *Stackit) Transform(ctx context.Context, template string, paramMap map[string]string, capabilities []string) (*string, error) {
+	if capabilities == nil {
+		capabilities = DefaultCapabilities
+	} else if err := CheckCapabilities(template, capabilities); err != nil {
+		return nil, err
+	}
+
 	params := []*cloudformation.Parameter{}
 	for name, value := range paramMap {
 		params = append(params, &cloudformation.Parameter{
@@ -21,11 +34,17 @@ func (s *Stackit) Transform(ctx context.Context, template string, paramMap map[s
 
 	stackName := fmt.Sprintf("stackit-temp-%d", time.Now().Unix())
 
+	templateBody, templateURL, err := s.templateLocation(ctx, transformPrefix, template)
+	if err != nil {
+		return nil, err
+	}
+
 	createResp, err := s.api.CreateChangeSetWithContext(ctx, &cloudformation.CreateChangeSetInput{
 		ChangeSetName: aws.String(fmt.Sprintf("csid-%d", time.Now().Unix())),
 		StackName:     &stackName,
-		Capabilities:  aws.StringSlice([]string{"CAPABILITY_IAM", "CAPABILITY_NAMED_IAM"}),
-		TemplateBody:  &template,
+		Capabilities:  aws.StringSlice(capabilities),
+		TemplateBody:  templateBody,
+		TemplateURL:   templateURL,
 		ChangeSetType: aws.String(cloudformation.ChangeSetTypeCreate),
 		Parameters:    params,
 	})
@@ -33,7 +52,7 @@ func (s *Stackit) Transform(ctx context.Context, template string, paramMap map[s
 		return nil, errors.Wrap(err, "creating change set")
 	}
 
-	_, err = changeset.Wait(ctx, s.api, *createResp.Id)
+	_, err = changeset.Wait(ctx, s.api, *createResp.Id, s.retry)
 	if err != nil {
 		return nil, errors.Wrap(err, "waiting for change set")
 	}
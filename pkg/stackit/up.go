@@ -19,18 +19,52 @@ type Template interface {
 }
 
 type StackitUpInput struct {
-	StackName        string
-	RoleARN          string
-	StackPolicyBody  string
-	Template         Template
-	PreviousTemplate bool
-	Parameters       []*cloudformation.Parameter
-	Tags             map[string]string
-	NotificationARNs []string
-	PopulateMissing  bool
+	StackName                   string
+	RoleARN                     string
+	StackPolicyBody             string
+	StackPolicyDuringUpdateBody string
+	Template                    Template
+	PreviousTemplate            bool
+	Parameters                  []*cloudformation.Parameter
+	Tags                        map[string]string
+	NotificationARNs            []string
+	PopulateMissing             bool
+
+	// Capabilities are granted to the change set. If nil, DefaultCapabilities
+	// are granted. If InferCapabilities is set, the capabilities required by
+	// the template are granted instead.
+	Capabilities      []string
+	InferCapabilities bool
+
+	// RollbackAlarmARNs are CloudWatch alarms that roll back the stack if
+	// they go into ALARM during the operation or during the following
+	// RollbackMonitoringMinutes.
+	RollbackAlarmARNs         []string
+	RollbackMonitoringMinutes int64
+
+	// TerminationProtection, if set, is applied to the stack once it has
+	// been successfully created or updated.
+	TerminationProtection *bool
+
+	// ResourcesToImport, if set, makes the change set an IMPORT change set
+	// that brings existing resources under the management of the stack.
+	ResourcesToImport []ResourceToImport
+
+	// WarnOnDrift runs drift detection on an existing stack before creating
+	// the change set and reports drifted resources that the change set would
+	// also change in PrepareOutput.DriftedResources. FailOnDrift does the
+	// same, but deletes the change set and returns a *DriftedChangesError.
+	WarnOnDrift bool
+	FailOnDrift bool
+
+	// ContinueUpdateRollback recovers stacks stuck in UPDATE_ROLLBACK_FAILED
+	// before creating the change set. If SkipFailedRollbackResources is also
+	// set, resources that failed to roll back are skipped during recovery.
+	ContinueUpdateRollback      bool
+	SkipFailedRollbackResources bool
 }
 
-func (s *Stackit) populateMissing(ctx context.Context, input *StackitUpInput) error {
+func (s *Stackit) populateMissing(ctx context.Context, input *StackitUpInput, templateBody, templateURL *string) error {
 	stack, _ := s.Describe(ctx, input.StackName)
 
 	maybeAddParam := func(name, defaultValue *string) {
@@ -56,7 +90,10 @@ func (s *Stackit) populateMissing(ctx context.Context, input *StackitUpInput) er
 			maybeAddParam(param.ParameterKey, nil)
 		}
 	} else {
-		resp, err := s.api.ValidateTemplateWithContext(ctx, &cloudformation.ValidateTemplateInput{TemplateBody: aws.String(input.Template.String())})
+		resp, err := s.api.ValidateTemplateWithContext(ctx, &cloudformation.ValidateTemplateInput{
+			TemplateBody: templateBody,
+			TemplateURL:  templateURL,
+		})
 		if err != nil {
 			return err
 		}
@@ -69,8 +106,8 @@ func (s *Stackit) populateMissing(ctx context.Context, input *StackitUpInput) er
 	return nil
 }
 
-func (s *Stackit) ensureStackReady(ctx context.Context, stackName string, events chan<- TailStackEvent) error {
-	stack, err := s.Describe(ctx, stackName)
+func (s *Stackit) ensureStackReady(ctx context.Context, input StackitUpInput, events chan<- TailStackEvent) error {
+	stack, err := s.Describe(ctx, input.StackName)
 	if err != nil {
 		return err
 	}
@@ -113,9 +150,53 @@ func (s *Stackit) ensureStackReady(ctx context.Context, stackName string, events
 			if len(resp.StackResourceSummaries) == 0 {
 				return cleanup(*stack.StackId)
 			}
+		} else if *stack.StackStatus == cloudformation.StackStatusUpdateRollbackFailed {
+			if !input.ContinueUpdateRollback {
+				return errors.Errorf("stack %s is in %s state and must be recovered by continuing the rollback", input.StackName, *stack.StackStatus)
+			}
+			return s.continueUpdateRollback(ctx, *stack.StackId, input.SkipFailedRollbackResources, events)
+		}
+	}
+
+	return nil
+}
+
+func (s *Stackit) continueUpdateRollback(ctx context.Context, stackId string, skipFailed bool, events chan<- TailStackEvent) error {
+	token := generateToken()
+	input := &cloudformation.ContinueUpdateRollbackInput{
+		StackName:          &stackId,
+		ClientRequestToken: &token,
+	}
+
+	if skipFailed {
+		var err error
+		input.ResourcesToSkip, err = s.resourcesInStatus(ctx, stackId, cloudformation.ResourceStatusUpdateFailed)
+		if err != nil {
+			return errors.Wrap(err, "determining resources to skip")
 		}
 	}
 
+	_, err := s.api.ContinueUpdateRollbackWithContext(ctx, input)
+	if err != nil {
+		return errors.Wrap(err, "continuing update rollback")
+	}
+
+	_, err = s.PollStackEvents(ctx, stackId, token, func(event TailStackEvent) {
+		events <- event
+	})
+	if err != nil {
+		return err
+	}
+
+	stack, err := s.Describe(ctx, stackId)
+	if err != nil {
+		return err
+	}
+
+	if *stack.StackStatus != cloudformation.StackStatusUpdateRollbackComplete {
+		return errors.Errorf("stack is in %s state after continuing rollback", *stack.StackStatus)
+	}
+
 	return nil
 }
 
@@ -132,10 +213,64 @@ type PrepareOutput struct {
 	Output       *cloudformation.CreateChangeSetOutput
 	Changes      []*cloudformation.Change
 	TemplateBody string
+
+	// DriftedResources are resources that have drifted and would be changed
+	// by the change set. Only populated when drift detection was requested.
+	DriftedResources []ResourceDrift
+}
+
+// Replacements returns the resource changes that will (or conditionally may)
+// replace an existing resource when the change set is executed.
+func (p *PrepareOutput) Replacements() []*cloudformation.ResourceChange {
+	var replacements []*cloudformation.ResourceChange
+
+	for _, change := range p.Changes {
+		rc := change.ResourceChange
+		if rc == nil || rc.Replacement == nil {
+			continue
+		}
+
+		if *rc.Replacement == cloudformation.ReplacementTrue || *rc.Replacement == cloudformation.ReplacementConditional {
+			replacements = append(replacements, rc)
+		}
+	}
+
+	return replacements
+}
+
+// deleteChangeSetTimeout limits how long deleting a change set that was
+// abandoned by a cancelled Prepare may take.
+const deleteChangeSetTimeout = time.Minute
+
+// AbandonedChangeSetError is returned by Prepare when ctx is cancelled (e.g.
+// because it timed out) while waiting for the change set to be created. The
+// change set is deleted so that it isn't left behind; DeleteErr is why it
+// couldn't be, if it wasn't.
+type AbandonedChangeSetError struct {
+	ChangeSetId string
+	DeleteErr   error
+	Err         error
+}
+
+func (e *AbandonedChangeSetError) Error() string {
+	if e.DeleteErr != nil {
+		return fmt.Sprintf("waiting for change set %s: %s (and couldn't delete it: %s)", e.ChangeSetId, e.Err, e.DeleteErr)
+	}
+	return fmt.Sprintf("waiting for change set %s: %s (deleted it)", e.ChangeSetId, e.Err)
 }
 
 func (s *Stackit) Prepare(ctx context.Context, input StackitUpInput, events chan<- TailStackEvent) (*PrepareOutput, error) {
-	err := s.ensureStackReady(ctx, input.StackName, events)
+	for _, policy := range []string{input.StackPolicyBody, input.StackPolicyDuringUpdateBody} {
+		if policy == "" {
+			continue
+		}
+
+		if err := ValidateStackPolicy(policy); err != nil {
+			return nil, errors.Wrap(err, "validating stack policy")
+		}
+	}
+
+	err := s.ensureStackReady(ctx, input, events)
 	if err != nil {
 		return nil, errors.Wrap(err, "waiting for stack to be in a clean state")
 	}
@@ -145,28 +280,53 @@ func (s *Stackit) Prepare(ctx context.Context, input StackitUpInput, events chan
 		return nil, errors.Wrap(err, "describing stack")
 	}
 
+	var driftReport *DriftReport
+	if (input.WarnOnDrift || input.FailOnDrift) && stack != nil && *stack.StackStatus != cloudformation.StackStatusReviewInProgress {
+		driftReport, err = s.DetectDrift(ctx, *stack.StackId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var templateBody, templateURL *string
+	if input.Template != nil {
+		templateBody, templateURL, err = s.templateLocation(ctx, input.StackName, input.Template.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if input.PopulateMissing && stack != nil {
-		err := s.populateMissing(ctx, &input)
+		err := s.populateMissing(ctx, &input, templateBody, templateURL)
 		if err != nil {
 			return nil, errors.Wrap(err, "populating missing parameters")
 		}
 	}
 
-	token := generateToken()
+	capabilities, err := s.capabilities(ctx, input)
+	if err != nil {
+		return nil, errors.Wrap(err, "determining capabilities")
+	}
 
-	createInput := &cloudformation.CreateChangeSetInput{
-		ChangeSetName:       aws.String(fmt.Sprintf("%s-csid-%d", input.StackName, time.Now().Unix())),
-		StackName:           &input.StackName,
-		Capabilities:        aws.StringSlice([]string{"CAPABILITY_IAM", "CAPABILITY_NAMED_IAM", "CAPABILITY_AUTO_EXPAND"}),
-		Parameters:          input.Parameters,
-		Tags:                mapToTags(input.Tags),
-		NotificationARNs:    aws.StringSlice(input.NotificationARNs),
-		ClientToken:         &token,
-		UsePreviousTemplate: &input.PreviousTemplate,
+	rollbackConfig, err := rollbackConfiguration(input.RollbackAlarmARNs, input.RollbackMonitoringMinutes)
+	if err != nil {
+		return nil, err
 	}
 
-	if input.Template != nil {
-		createInput.TemplateBody = aws.String(input.Template.String())
+	token := generateToken()
+
+	createInput := &cloudformation.CreateChangeSetInput{
+		ChangeSetName:         aws.String(fmt.Sprintf("%s-csid-%d", input.StackName, time.Now().Unix())),
+		StackName:             &input.StackName,
+		Capabilities:          aws.StringSlice(capabilities),
+		Parameters:            input.Parameters,
+		Tags:                  mapToTags(input.Tags),
+		NotificationARNs:      aws.StringSlice(input.NotificationARNs),
+		ClientToken:           &token,
+		UsePreviousTemplate:   &input.PreviousTemplate,
+		TemplateBody:          templateBody,
+		TemplateURL:           templateURL,
+		RollbackConfiguration: rollbackConfig,
 	}
 
 	if roleArn := input.RoleARN; len(roleArn) > 0 {
@@ -180,7 +340,13 @@ func (s *Stackit) Prepare(ctx context.Context, input StackitUpInput, events chan
 		createInput.RoleARN = &roleArn
 	}
 
-	if stack != nil { // stack already exists
+	if len(input.ResourcesToImport) > 0 {
+		createInput.ChangeSetType = aws.String(cloudformation.ChangeSetTypeImport)
+		createInput.ResourcesToImport, err = s.resourcesToImport(ctx, templateBody, templateURL, input.ResourcesToImport)
+		if err != nil {
+			return nil, errors.Wrap(err, "determining resources to import")
+		}
+	} else if stack != nil { // stack already exists
 		createInput.ChangeSetType = aws.String(cloudformation.ChangeSetTypeUpdate)
 	} else {
 		createInput.ChangeSetType = aws.String(cloudformation.ChangeSetTypeCreate)
@@ -191,12 +357,19 @@ func (s *Stackit) Prepare(ctx context.Context, input StackitUpInput, events chan
 		return nil, errors.Wrap(err, "creating change set")
 	}
 
-	change, err := changeset.Wait(ctx, s.api, *resp.Id)
+	change, err := changeset.Wait(ctx, s.api, *resp.Id, s.retry)
 	if _, ok := err.(*changeset.NoOpChangesetError); ok {
 		_, err = s.api.DeleteChangeSetWithContext(ctx, &cloudformation.DeleteChangeSetInput{ChangeSetName: resp.Id})
 		return nil, errors.Wrap(err, "waiting for no-op changeset to delete")
 	}
 
+	if err != nil && ctx.Err() != nil {
+		deleteCtx, cancel := context.WithTimeout(context.Background(), deleteChangeSetTimeout)
+		_, deleteErr := s.api.DeleteChangeSetWithContext(deleteCtx, &cloudformation.DeleteChangeSetInput{ChangeSetName: resp.Id})
+		cancel()
+		return nil, &AbandonedChangeSetError{ChangeSetId: *resp.Id, DeleteErr: deleteErr, Err: ctx.Err()}
+	}
+
 	if err != nil {
 		spew.Dump(err)
 		return nil, errors.Wrap(err, "waiting for changeset to stabilise")
@@ -211,12 +384,25 @@ func (s *Stackit) Prepare(ctx context.Context, input StackitUpInput, events chan
 		return nil, errors.Wrap(err, "getting processed template body")
 	}
 
-	return &PrepareOutput{
+	output := &PrepareOutput{
 		Input:        createInput,
 		Output:       resp,
 		Changes:      change.Changes,
 		TemplateBody: *getResp.TemplateBody,
-	}, nil
+	}
+
+	if driftReport != nil {
+		output.DriftedResources = driftedChanges(driftReport, change.Changes)
+		if input.FailOnDrift && len(output.DriftedResources) > 0 {
+			_, err = s.api.DeleteChangeSetWithContext(ctx, &cloudformation.DeleteChangeSetInput{ChangeSetName: resp.Id})
+			if err != nil {
+				return nil, errors.Wrap(err, "deleting change set of drifted stack")
+			}
+			return nil, &DriftedChangesError{StackName: input.StackName, Resources: output.DriftedResources}
+		}
+	}
+
+	return output, nil
 }
 
 func (s *Stackit) Execute(ctx context.Context, stackId, changeSetId string, events chan<- TailStackEvent) error {
@@ -231,9 +417,11 @@ func (s *Stackit) Execute(ctx context.Context, stackId, changeSetId string, even
 		return errors.Wrap(err, "executing change set")
 	}
 
+	record := s.recordEvents(stackId)
 	_, err = s.PollStackEvents(ctx, stackId, token, func(event TailStackEvent) {
+		record(event)
 		events <- event
 	})
 
-	return nil
+	return err
 }
@@ -82,3 +82,70 @@ func TestNoOpChangesetTriggersDelete(t *testing.T) {
 func TestChangesetErrorIsReported(t *testing.T) {
 	t.SkipNow()
 }
+
+func TestUpdateRollbackFailedRequiresOptIn(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateRollbackFailed), nil)
+
+	s := NewStackit(capi, &mockSts{})
+	_, err := s.Prepare(context.Background(), StackitUpInput{StackName: "stack-name"}, make(chan TailStackEvent))
+	assert.EqualError(t, err, "waiting for stack to be in a clean state: stack stack-name is in UPDATE_ROLLBACK_FAILED state and must be recovered by continuing the rollback")
+}
+
+func TestContinueUpdateRollbackSkipsFailedResources(t *testing.T) {
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(describeStacksOutput(cloudformation.StackStatusUpdateRollbackFailed), nil)
+	capi.On("ListStackResourcesPagesWithContext", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		cb := args.Get(2).(func(*cloudformation.ListStackResourcesOutput, bool) bool)
+		cb(&cloudformation.ListStackResourcesOutput{
+			StackResourceSummaries: []*cloudformation.StackResourceSummary{
+				{LogicalResourceId: aws.String("Broken"), ResourceStatus: aws.String(cloudformation.ResourceStatusUpdateFailed)},
+				{LogicalResourceId: aws.String("Fine"), ResourceStatus: aws.String(cloudformation.ResourceStatusUpdateComplete)},
+			},
+		}, true)
+	})
+	capi.On("ContinueUpdateRollbackWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("done")).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*cloudformation.ContinueUpdateRollbackInput)
+		assert.Equal(t, []string{"Broken"}, aws.StringValueSlice(input.ResourcesToSkip))
+		assert.NotEmpty(t, *input.ClientRequestToken)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{
+		StackName:                   "stack-name",
+		ContinueUpdateRollback:      true,
+		SkipFailedRollbackResources: true,
+	}
+	_, err := s.Prepare(context.Background(), input, make(chan TailStackEvent))
+	assert.EqualError(t, err, "waiting for stack to be in a clean state: continuing update rollback: done")
+}
+
+func TestPrepareDeletesChangeSetWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	capi := &mockCfn{}
+	capi.On("DescribeStacksWithContext", mock.Anything, mock.Anything, mock.Anything).Return(nil, awserr.New("ValidationError", "", nil))
+	capi.On("CreateChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.CreateChangeSetOutput{
+		Id:      aws.String("change-set-id"),
+		StackId: aws.String("stack-id"),
+	}, nil)
+	capi.On("DescribeChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DescribeChangeSetOutput{
+		Status: aws.String(cloudformation.ChangeSetStatusCreateInProgress),
+	}, nil).Run(func(args mock.Arguments) {
+		cancel()
+	})
+	capi.On("DeleteChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything).Return(&cloudformation.DeleteChangeSetOutput{}, nil).Run(func(args mock.Arguments) {
+		assert.NoError(t, args.Get(0).(context.Context).Err())
+		assert.Equal(t, "change-set-id", *args.Get(1).(*cloudformation.DeleteChangeSetInput).ChangeSetName)
+	})
+
+	s := NewStackit(capi, &mockSts{})
+	input := StackitUpInput{
+		StackName: "stack-name",
+		Template:  stringTemplate("Resources: {}"),
+	}
+	_, err := s.Prepare(ctx, input, make(chan TailStackEvent))
+	assert.Equal(t, &AbandonedChangeSetError{ChangeSetId: "change-set-id", Err: context.Canceled}, err)
+	capi.AssertCalled(t, "DeleteChangeSetWithContext", mock.Anything, mock.Anything, mock.Anything)
+}